@@ -0,0 +1,185 @@
+package owners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Approvals struct {
+	Sections []SectionApproval `json:"sections"`
+}
+
+type SectionApproval struct {
+	Name     string `json:"name"`
+	Optional bool   `json:"optional"`
+	// Number of approvals required from the owners of each file.
+	Approvals int `json:"approvals"`
+	// Approvers who are owners of at least one file in the section.
+	Approvers []string `json:"approvers"`
+	FilePaths []string `json:"files"`
+	// Whether every file in the section has enough approvals.
+	Satisfied bool `json:"satisfied"`
+}
+
+// TeamMembersFunc returns the members of a team owner such as @org/team.
+type TeamMembersFunc func(team string) ([]string, error)
+
+// FindApprovals checks for every changed file whether each section that
+// matched it has received its number of approvals from the owners of the
+// file. Team owners are expanded with teamMembers.
+func (m *Matcher) FindApprovals(filePaths []string, approvers []string, teamMembers TeamMembersFunc) (Approvals, error) {
+	seen := make(map[string]bool)
+	var uniqueApprovers []string
+	for _, approver := range approvers {
+		if !seen[normalizeHandle(approver)] {
+			seen[normalizeHandle(approver)] = true
+			uniqueApprovers = append(uniqueApprovers, approver)
+		}
+	}
+
+	teamToMembers := make(map[string][]string)
+	eligibleApprovers := func(owners []string) (map[string]bool, error) {
+		eligible := make(map[string]bool)
+		for _, owner := range owners {
+			if !isTeamOwner(owner) {
+				eligible[normalizeHandle(owner)] = true
+				continue
+			}
+
+			members, ok := teamToMembers[owner]
+			if !ok {
+				var err error
+				members, err = teamMembers(owner)
+				if err != nil {
+					return nil, err
+				}
+				teamToMembers[owner] = members
+			}
+			for _, member := range members {
+				eligible[normalizeHandle(member)] = true
+			}
+		}
+		return eligible, nil
+	}
+
+	nameToSection := make(map[string]*SectionApproval)
+	for _, filePath := range filePaths {
+		sectionMatches, err := m.MatchSections(filePath)
+		if err != nil {
+			return Approvals{}, err
+		}
+
+		for _, sectionMatch := range sectionMatches {
+			section, ok := nameToSection[sectionMatch.Name]
+			if !ok {
+				section = &SectionApproval{Name: sectionMatch.Name, Optional: true, Satisfied: true}
+				nameToSection[sectionMatch.Name] = section
+			}
+
+			eligible, err := eligibleApprovers(sectionMatch.Owners)
+			if err != nil {
+				return Approvals{}, err
+			}
+
+			numApprovals := 0
+			for _, approver := range uniqueApprovers {
+				if eligible[normalizeHandle(approver)] {
+					numApprovals++
+					section.Approvers = appendUnique(section.Approvers, approver)
+				}
+			}
+
+			// A section with the same name can be required in one owners file and optional in another.
+			section.Optional = section.Optional && sectionMatch.Optional
+			if sectionMatch.Approvals > section.Approvals {
+				section.Approvals = sectionMatch.Approvals
+			}
+			section.Satisfied = section.Satisfied && numApprovals >= sectionMatch.Approvals
+			section.FilePaths = append(section.FilePaths, filePath)
+		}
+	}
+
+	var approvals Approvals
+	for _, section := range nameToSection {
+		sort.Strings(section.Approvers)
+		sort.Strings(section.FilePaths)
+		approvals.Sections = append(approvals.Sections, *section)
+	}
+	sort.Slice(approvals.Sections, func(i, j int) bool {
+		return approvals.Sections[i].Name < approvals.Sections[j].Name
+	})
+
+	return approvals, nil
+}
+
+func isTeamOwner(owner string) bool {
+	return strings.HasPrefix(owner, "@") && strings.Contains(owner, "/")
+}
+
+// Satisfied reports whether all required sections have enough approvals.
+func (a Approvals) Satisfied() bool {
+	for _, section := range a.Sections {
+		if !section.Optional && !section.Satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+// Description summarizes the approval status in a single short line.
+func (a Approvals) Description() string {
+	numRequired, numSatisfied := 0, 0
+	for _, section := range a.Sections {
+		if section.Optional {
+			continue
+		}
+		numRequired++
+		if section.Satisfied {
+			numSatisfied++
+		}
+	}
+	if numRequired == 0 {
+		return "No approvals required"
+	}
+	return fmt.Sprintf("%d of %d required sections approved", numSatisfied, numRequired)
+}
+
+// Markdown renders a table with the approval status of each section.
+func (a Approvals) Markdown() string {
+	w := &strings.Builder{}
+
+	writeLinef := func(format string, args ...interface{}) {
+		w.WriteString(fmt.Sprintf(format, args...))
+		w.WriteRune('\n')
+	}
+
+	if len(a.Sections) == 0 {
+		writeLinef("No approvals required.")
+		return w.String()
+	}
+
+	writeLinef("| Section | Required | Approvals | Approved by | Files | Status |")
+	writeLinef("|-|-|-|-|-|-|")
+	for _, section := range a.Sections {
+		var required string
+		if !section.Optional {
+			required = "✅"
+		}
+
+		status := "⏳"
+		if section.Satisfied {
+			status = "✅"
+		}
+
+		// Backticks keep approvers from being mentioned.
+		var approvers []string
+		for _, approver := range section.Approvers {
+			approvers = append(approvers, "`"+approver+"`")
+		}
+
+		writeLinef("| %s | %s | %d | %s | %d | %s |", section.Name, required, section.Approvals, strings.Join(approvers, " "), len(section.FilePaths), status)
+	}
+
+	return w.String()
+}
@@ -0,0 +1,67 @@
+package owners
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcherFindApprovals(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte(`
+		[backend][2] @org/backend
+		*.go
+		legacy.go @alice
+
+		^[docs]
+		*.md @docs
+		`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte(`
+		[backend] @bob
+		*.go
+		`), 0644)
+	assert.NoError(t, err)
+
+	matcher := newMatcherWithFs("OWNERS", fs)
+	teamMembers := func(team string) ([]string, error) {
+		if team == "@org/backend" {
+			return []string{"@alice", "@carol"}, nil
+		}
+		return nil, fmt.Errorf("unknown team %s", team)
+	}
+
+	approvals, err := matcher.FindApprovals([]string{"main.go", "a/a.go", "readme.md"}, []string{"@alice", "@Bob"}, teamMembers)
+	assert.NoError(t, err)
+	assert.Equal(t, Approvals{Sections: []SectionApproval{
+		{Name: "backend", Approvals: 2, Approvers: []string{"@Bob", "@alice"}, FilePaths: []string{"a/a.go", "main.go"}, Satisfied: false},
+		{Name: "docs", Optional: true, Approvals: 1, FilePaths: []string{"readme.md"}, Satisfied: false},
+	}}, approvals)
+	assert.False(t, approvals.Satisfied())
+	assert.Equal(t, "0 of 1 required sections approved", approvals.Description())
+
+	approvals, err = matcher.FindApprovals([]string{"main.go", "a/a.go", "readme.md"}, []string{"@alice", "@carol", "@bob"}, teamMembers)
+	assert.NoError(t, err)
+	assert.True(t, approvals.Sections[0].Satisfied)
+	assert.True(t, approvals.Satisfied())
+	assert.Equal(t, "1 of 1 required sections approved", approvals.Description())
+	assert.Equal(t, "| Section | Required | Approvals | Approved by | Files | Status |\n"+
+		"|-|-|-|-|-|-|\n"+
+		"| backend | ✅ | 2 | `@alice` `@bob` `@carol` | 2 | ✅ |\n"+
+		"| docs |  | 1 |  | 1 | ⏳ |\n", approvals.Markdown())
+
+	// A single approval is enough for legacy.go, but the section asks for two.
+	approvals, err = matcher.FindApprovals([]string{"legacy.go"}, []string{"@alice"}, teamMembers)
+	assert.NoError(t, err)
+	assert.False(t, approvals.Satisfied())
+
+	_, err = matcher.FindApprovals([]string{"main.go"}, nil, func(string) ([]string, error) {
+		return nil, fmt.Errorf("boom")
+	})
+	assert.EqualError(t, err, "boom")
+}
@@ -0,0 +1,110 @@
+package owners
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Bitbucket Server limits the length of comment text.
+	maxBitbucketCommentLength = 32768
+	// Bitbucket Server escapes HTML in comments, but hides link reference
+	// definitions like this one.
+	bitbucketCommentMarker = "[//]: # (github.com/martin-vanta/owners)"
+)
+
+// BitbucketServerNotifier posts comments on a pull request of Bitbucket
+// Server or Data Center.
+type BitbucketServerNotifier struct {
+	// Base URL of the server, e.g. https://bitbucket.example.com.
+	URL string
+	// Repository in PROJECT/repo form.
+	Repository  string
+	PullRequest int
+	// HTTP access token or personal access token.
+	Token string
+}
+
+var _ Notifier = (*BitbucketServerNotifier)(nil)
+
+func (n *BitbucketServerNotifier) pullRequestPath() string {
+	project, repo, _ := strings.Cut(n.Repository, "/")
+	return fmt.Sprintf("/projects/%s/repos/%s/pull-requests/%d", project, repo, n.PullRequest)
+}
+
+func (n *BitbucketServerNotifier) FindComments() ([]Comment, error) {
+	var comments []Comment
+	start := 0
+	for {
+		activities := struct {
+			Values []struct {
+				Action        string `json:"action"`
+				CommentAction string `json:"commentAction"`
+				Comment       struct {
+					Id      int64  `json:"id"`
+					Version int    `json:"version"`
+					Text    string `json:"text"`
+				} `json:"comment"`
+			} `json:"values"`
+			IsLastPage    bool `json:"isLastPage"`
+			NextPageStart int  `json:"nextPageStart"`
+		}{}
+		path := fmt.Sprintf("%s/activities?start=%d&limit=100", n.pullRequestPath(), start)
+		if err := n.restApi("GET", path, nil, &activities); err != nil {
+			return nil, err
+		}
+
+		for _, activity := range activities.Values {
+			if activity.Action != "COMMENTED" || activity.CommentAction != "ADDED" || !strings.HasPrefix(activity.Comment.Text, bitbucketCommentMarker) {
+				continue
+			}
+			comments = append(comments, Comment{
+				ID:      strconv.FormatInt(activity.Comment.Id, 10),
+				Body:    activity.Comment.Text,
+				Version: activity.Comment.Version,
+			})
+		}
+		if activities.IsLastPage {
+			break
+		}
+		start = activities.NextPageStart
+	}
+
+	// Activities are listed newest first.
+	for i, j := 0, len(comments)-1; i < j; i, j = i+1, j-1 {
+		comments[i], comments[j] = comments[j], comments[i]
+	}
+	return comments, nil
+}
+
+func (n *BitbucketServerNotifier) AddComment(body string) error {
+	return n.restApi("POST", n.pullRequestPath()+"/comments", map[string]interface{}{"text": body}, nil)
+}
+
+func (n *BitbucketServerNotifier) UpdateComment(comment Comment, body string) error {
+	return n.restApi("PUT", n.pullRequestPath()+"/comments/"+comment.ID, map[string]interface{}{
+		"text":    body,
+		"version": comment.Version,
+	}, nil)
+}
+
+func (n *BitbucketServerNotifier) DeleteComment(comment Comment) error {
+	path := fmt.Sprintf("%s/comments/%s?version=%d", n.pullRequestPath(), comment.ID, comment.Version)
+	return n.restApi("DELETE", path, nil, nil)
+}
+
+func (n *BitbucketServerNotifier) MaxCommentLength() int {
+	return maxBitbucketCommentLength
+}
+
+func (n *BitbucketServerNotifier) commentMarker() string {
+	return bitbucketCommentMarker
+}
+
+func (n *BitbucketServerNotifier) restApi(method, path string, requestData interface{}, responseData interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+n.Token)
+	return restRequest(method, strings.TrimSuffix(n.URL, "/")+"/rest/api/1.0"+path, header, requestData, responseData)
+}
@@ -0,0 +1,324 @@
+package owners
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version of the cached owners file format. Bumping it invalidates every
+// cached file, e.g. after adding fields to OwnersFile.
+const ownersCacheVersion = 3
+
+// ownersCache stores compiled owners files on disk, keyed by the git blob
+// hash of their contents. A changed owners file has a new hash, so entries
+// never go stale. The cache is best effort: entries that can not be read or
+// written are parsed again.
+//
+// Entries hold the parsed file and the kind of each rule pattern in a compact
+// binary encoding, which decodes several times faster than parsing the file
+// with regular expressions and classifying its patterns again.
+type ownersCache struct {
+	dir string
+}
+
+// DefaultCacheDir returns the owners-cache directory in the git directory of
+// the working tree.
+func DefaultCacheDir() (string, error) {
+	dir, err := run("git", "rev-parse", "--git-path", "owners-cache")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(dir), nil
+}
+
+// WithCache caches compiled owners files in dir, so that later matchers skip
+// parsing owners files that did not change.
+func WithCache(dir string) MatcherOption {
+	return func(m *Matcher) {
+		m.cache = &ownersCache{dir: dir}
+	}
+}
+
+// gitBlobHash returns the object id that git assigns to a blob with data.
+func gitBlobHash(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *ownersCache) path(hash string) string {
+	return filepath.Join(c.dir, fmt.Sprintf("v%d", ownersCacheVersion), hash[:2], hash[2:])
+}
+
+// get returns the cached owners file with contents data and its index. The
+// path of the owners file is not cached since identical files can be in many
+// directories.
+func (c *ownersCache) get(path string, data []byte) (*OwnersFile, *fileIndex, bool) {
+	cached, err := os.ReadFile(c.path(gitBlobHash(data)))
+	if err != nil {
+		return nil, nil, false
+	}
+	ownersFile, index, err := decodeOwnersFile(cached)
+	if err != nil {
+		return nil, nil, false
+	}
+	ownersFile.Path = path
+	return ownersFile, index, true
+}
+
+func (c *ownersCache) put(data []byte, ownersFile *OwnersFile, index *fileIndex) error {
+	encoded := encodeOwnersFile(ownersFile, index)
+
+	cachePath := c.path(gitBlobHash(data))
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that concurrent readers never see
+	// a partially written entry.
+	tmp, err := os.CreateTemp(filepath.Dir(cachePath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), cachePath)
+}
+
+// encodeOwnersFile encodes ownersFile without its path, and the kinds of the
+// rules in index. Numbers are uvarints and strings are length prefixed.
+// Lists are prefixed with their length plus one, or zero if they are nil, so
+// that they decode to equal values.
+func encodeOwnersFile(ownersFile *OwnersFile, index *fileIndex) []byte {
+	e := &cacheEncoder{}
+	e.list(len(ownersFile.Settings), ownersFile.Settings == nil)
+	for _, setting := range ownersFile.Settings {
+		e.string(setting.Name)
+		e.source(&setting.Source)
+	}
+	e.list(len(ownersFile.Aliases), ownersFile.Aliases == nil)
+	for _, alias := range ownersFile.Aliases {
+		e.string(alias.Name)
+		e.strings(alias.Owners)
+		e.source(&alias.Source)
+	}
+	e.list(len(ownersFile.Sections), ownersFile.Sections == nil)
+	for i, section := range ownersFile.Sections {
+		e.string(section.Name)
+		e.bool(section.Optional)
+		e.uint(uint64(section.Approvals))
+		e.strings(section.DefaultOwners)
+		e.source(&section.Source)
+		e.list(len(section.Rules), section.Rules == nil)
+		for j, rule := range section.Rules {
+			e.string(rule.Pattern)
+			e.bool(rule.Negated)
+			e.strings(rule.Owners)
+			e.source(&rule.Source)
+			e.uint(uint64(index.sections[i].kinds[j]))
+		}
+	}
+	e.strings(ownersFile.TrailingComments)
+	return e.buf
+}
+
+func decodeOwnersFile(data []byte) (*OwnersFile, *fileIndex, error) {
+	// Decoded strings share the memory of a single conversion.
+	d := &cacheDecoder{data: string(data)}
+	ownersFile := &OwnersFile{}
+	index := &fileIndex{}
+
+	if n, ok := d.list(); ok {
+		ownersFile.Settings = make([]*Setting, n)
+		for i := range ownersFile.Settings {
+			setting := &Setting{Name: d.string()}
+			d.source(&setting.Source)
+			ownersFile.Settings[i] = setting
+		}
+	}
+	if n, ok := d.list(); ok {
+		ownersFile.Aliases = make([]*Alias, n)
+		for i := range ownersFile.Aliases {
+			alias := &Alias{Name: d.string(), Owners: d.strings()}
+			d.source(&alias.Source)
+			ownersFile.Aliases[i] = alias
+		}
+	}
+	if n, ok := d.list(); ok {
+		ownersFile.Sections = make([]*Section, n)
+		for i := range ownersFile.Sections {
+			section := &Section{
+				Name:          d.string(),
+				Optional:      d.bool(),
+				Approvals:     int(d.uint()),
+				DefaultOwners: d.strings(),
+			}
+			d.source(&section.Source)
+			if n, ok := d.list(); ok {
+				section.Rules = make([]*Rule, n)
+			}
+			kinds := make([]ruleKind, len(section.Rules))
+			for j := range section.Rules {
+				rule := &Rule{Pattern: d.string(), Negated: d.bool(), Owners: d.strings()}
+				d.source(&rule.Source)
+				kind := d.uint()
+				if kind > uint64(ruleInvalidGlob) {
+					d.err = errInvalidCacheEntry
+				}
+				kinds[j] = ruleKind(kind)
+				section.Rules[j] = rule
+			}
+			if d.err != nil {
+				return nil, nil, d.err
+			}
+			ownersFile.Sections[i] = section
+			index.sections = append(index.sections, newSectionIndexOfKinds(section, kinds))
+		}
+	}
+	ownersFile.TrailingComments = d.strings()
+
+	if d.err == nil && d.pos != len(d.data) {
+		d.err = errInvalidCacheEntry
+	}
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	return ownersFile, index, nil
+}
+
+var errInvalidCacheEntry = errors.New("invalid cache entry")
+
+type cacheEncoder struct {
+	buf []byte
+}
+
+func (e *cacheEncoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *cacheEncoder) bool(v bool) {
+	if v {
+		e.uint(1)
+	} else {
+		e.uint(0)
+	}
+}
+
+func (e *cacheEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *cacheEncoder) list(n int, isNil bool) {
+	if isNil {
+		e.uint(0)
+	} else {
+		e.uint(uint64(n) + 1)
+	}
+}
+
+func (e *cacheEncoder) strings(s []string) {
+	e.list(len(s), s == nil)
+	for _, v := range s {
+		e.string(v)
+	}
+}
+
+func (e *cacheEncoder) source(source *Source) {
+	e.uint(uint64(source.Line))
+	e.string(source.Raw)
+	e.string(source.Comment)
+	e.strings(source.Doc)
+}
+
+// cacheDecoder reads values written by cacheEncoder. After the first error
+// it returns zero values and keeps the error.
+type cacheDecoder struct {
+	data string
+	pos  int
+	err  error
+}
+
+func (d *cacheDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var v uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if d.pos >= len(d.data) {
+			break
+		}
+		b := d.data[d.pos]
+		d.pos++
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+	d.err = errInvalidCacheEntry
+	return 0
+}
+
+func (d *cacheDecoder) bool() bool {
+	return d.uint() != 0
+}
+
+func (d *cacheDecoder) string() string {
+	n := d.uint()
+	if d.err != nil {
+		return ""
+	}
+	if n > uint64(len(d.data)-d.pos) {
+		d.err = errInvalidCacheEntry
+		return ""
+	}
+	s := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return s
+}
+
+// list returns the length of a list and whether it is not nil.
+func (d *cacheDecoder) list() (int, bool) {
+	n := d.uint()
+	if n == 0 || d.err != nil {
+		return 0, false
+	}
+	// Every element takes at least one byte, which bounds allocations for
+	// corrupt entries.
+	if n-1 > uint64(len(d.data)-d.pos) {
+		d.err = errInvalidCacheEntry
+		return 0, false
+	}
+	return int(n - 1), true
+}
+
+func (d *cacheDecoder) strings() []string {
+	n, ok := d.list()
+	if !ok {
+		return nil
+	}
+	s := make([]string, n)
+	for i := range s {
+		s[i] = d.string()
+	}
+	return s
+}
+
+func (d *cacheDecoder) source(source *Source) {
+	source.Line = int(d.uint())
+	source.Raw = d.string()
+	source.Comment = d.string()
+	source.Doc = d.strings()
+}
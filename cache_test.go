@@ -0,0 +1,70 @@
+package owners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitBlobHash(t *testing.T) {
+	// git hash-object of an empty file and of "hello\n".
+	assert.Equal(t, "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391", gitBlobHash(nil))
+	assert.Equal(t, "ce013625030ba8dba906f756967f9e9ca394464a", gitBlobHash([]byte("hello\n")))
+}
+
+func TestMatcherCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	contents := []byte(`# Owners.
+set inherit
+@alias go = @alice @bob
+[go][2] @go
+**/*.go
+!**/*_generated.go
+^[docs]
+*.md jane@example.com # Docs.
+`)
+
+	fs := afero.NewMemMapFs()
+	err := fs.MkdirAll("a", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", contents, 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", contents, 0644)
+	assert.NoError(t, err)
+
+	uncached, err := newMatcherWithFs("OWNERS", fs).Load("a")
+	assert.NoError(t, err)
+
+	// The first matcher parses and caches the file.
+	parsed, err := newMatcherWithFs("OWNERS", fs, WithCache(cacheDir)).Load("a")
+	assert.NoError(t, err)
+	assert.Equal(t, uncached, parsed)
+
+	cachePath := filepath.Join(cacheDir, fmt.Sprintf("v%d", ownersCacheVersion), gitBlobHash(contents)[:2], gitBlobHash(contents)[2:])
+	assert.FileExists(t, cachePath)
+
+	// Later matchers read the cached file, with the path of the loaded file.
+	matcher := newMatcherWithFs("OWNERS", fs, WithCache(cacheDir))
+	cached, err := matcher.Load("a")
+	assert.NoError(t, err)
+	assert.Equal(t, uncached, cached)
+	assert.Equal(t, newFileIndex(uncached), matcher.index(cached))
+	root, err := matcher.Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, "OWNERS", root.Path)
+
+	// Corrupt and truncated entries are parsed again.
+	encoded, err := os.ReadFile(cachePath)
+	assert.NoError(t, err)
+	for _, corrupt := range [][]byte{[]byte("\xff"), encoded[:len(encoded)-1], append(encoded, 0)} {
+		err = os.WriteFile(cachePath, corrupt, 0644)
+		assert.NoError(t, err)
+		reparsed, err := newMatcherWithFs("OWNERS", fs, WithCache(cacheDir)).Load("a")
+		assert.NoError(t, err)
+		assert.Equal(t, uncached, reparsed)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <path>...",
+	Short: "Explain which rules match files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  explainRun,
+}
+
+var (
+	explainOutputFormat string
+)
+
+func init() {
+	explainCmd.PersistentFlags().StringVarP(&explainOutputFormat, "output", "o", "text", `output format (one of "text", "json")`)
+}
+
+func explainRun(cmd *cobra.Command, args []string) error {
+	matcher := owners.NewMatcher(ownersFileName, matcherOptions()...)
+
+	var explanations []*owners.Explanation
+	for _, filePath := range args {
+		explanation, err := matcher.Explain(filePath)
+		if err != nil {
+			return err
+		}
+		explanations = append(explanations, explanation)
+	}
+
+	switch explainOutputFormat {
+	case "text":
+		for _, explanation := range explanations {
+			fmt.Println(explanation.String())
+		}
+	case "json":
+		data, err := json.MarshalIndent(explanations, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unknown output format: %s", explainOutputFormat)
+	}
+
+	return nil
+}
@@ -17,12 +17,14 @@ var findCmd = &cobra.Command{
 var (
 	changedFilesFilePath string
 	gitSince             string
+	ownersRev            string
 	outputFormat         string
 )
 
 func init() {
 	findCmd.PersistentFlags().StringVarP(&changedFilesFilePath, "file", "f", "", "file with list of file names")
 	findCmd.PersistentFlags().StringVarP(&gitSince, "since", "", "", "files changed since this git ref")
+	findCmd.PersistentFlags().StringVarP(&ownersRev, "rev", "", "", "read owners files at this git ref instead of the working tree")
 	findCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", `output format (one of "text", "json")`)
 }
 
@@ -41,7 +43,13 @@ func findRun(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	results, err := owners.FindOwners(ownersFileName, diffs)
+	matcher := owners.NewMatcher(ownersFileName, matcherOptions()...)
+	if ownersRev != "" {
+		matcher = owners.NewMatcherAt(ownersFileName, ownersRev, matcherOptions()...)
+	}
+	defer matcher.Close()
+
+	results, err := matcher.FindOwners(diffs)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:          "fmt [path]...",
+	Short:        "Format owners files",
+	RunE:         fmtRun,
+	SilenceUsage: true,
+}
+
+var (
+	fmtCheck bool
+	fmtWrite bool
+)
+
+func init() {
+	fmtCmd.PersistentFlags().BoolVarP(&fmtCheck, "check", "", false, "list files that are not formatted and fail if there are any")
+	fmtCmd.PersistentFlags().BoolVarP(&fmtWrite, "write", "w", false, "write formatted contents back to files")
+}
+
+func fmtRun(cmd *cobra.Command, args []string) error {
+	filePaths := args
+	if len(filePaths) == 0 {
+		var err error
+		filePaths, err = owners.FindAllOwnersFiles(ownersFileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var unformatted []string
+	for _, filePath := range filePaths {
+		src, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		formatted, err := owners.Format(filePath, src)
+		if err != nil {
+			return fmt.Errorf("failed to format file %s: %w", filePath, err)
+		}
+
+		if !fmtCheck && !fmtWrite {
+			fmt.Print(string(formatted))
+			continue
+		}
+
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+		unformatted = append(unformatted, filePath)
+		fmt.Println(filePath)
+
+		if fmtWrite {
+			info, err := os.Stat(filePath)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filePath, formatted, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if fmtCheck && !fmtWrite && len(unformatted) > 0 {
+		return fmt.Errorf("%d file(s) are not formatted, run owners fmt --write", len(unformatted))
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/martin-vanta/owners"
 	"github.com/spf13/cobra"
 )
@@ -11,30 +14,204 @@ var githubCmd = &cobra.Command{
 	RunE:  githubRun,
 }
 
+var githubStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Reports section approvals of a pull request as a commit status",
+	RunE:  githubStatusRun,
+}
+
+var (
+	githubExclude        []string
+	githubRequestReviews bool
+	githubCheckRun       bool
+	githubRequireOwners  bool
+	githubValidateOwners bool
+	githubDryRun         bool
+	githubWorkingTree    bool
+	githubEvent          string
+	githubEventName      string
+)
+
+func init() {
+	githubCmd.Flags().StringSliceVarP(&githubExclude, "exclude", "", nil, "owners that are never notified, e.g. bot accounts")
+	githubCmd.Flags().BoolVarP(&githubRequestReviews, "request-reviews", "", false, "request reviews from required owners")
+	githubCmd.Flags().BoolVarP(&githubCheckRun, "check-run", "", false, "publish owners of changed files as a check run")
+	githubCmd.Flags().BoolVarP(&githubRequireOwners, "require-owners", "", false, "fail the check run if a changed file has no required owners")
+	githubCmd.Flags().BoolVarP(&githubValidateOwners, "validate-owners", "", false, "fail if owners of changed files are not GitHub users or teams with write access")
+	githubCmd.Flags().BoolVarP(&githubDryRun, "dry-run", "", false, "print the comment instead of posting it")
+	githubCmd.Flags().BoolVarP(&githubWorkingTree, "working-tree", "", false, "with --dry-run, read owners files from the working tree to preview edits to them")
+	githubCmd.Flags().StringVarP(&githubEvent, "event", "", "", "path of the event JSON, defaults to GITHUB_EVENT_PATH")
+	githubCmd.Flags().StringVarP(&githubEventName, "event-name", "", "", "name of the event, defaults to GITHUB_EVENT_NAME or pull_request")
+
+	githubCmd.AddCommand(githubStatusCmd)
+}
+
 func githubRun(cmd *cobra.Command, args []string) error {
-	actions, err := owners.GetGitHubActions()
+	actions, err := githubActions()
 	if err != nil {
 		return err
 	}
 
+	if githubDryRun {
+		return githubDryRunRun(actions)
+	}
+
+	if actions.Draft || actions.Skip {
+		return nil
+	}
+
+	matcher, diffs, err := githubChanges(actions)
+	if err != nil {
+		return err
+	}
+	defer matcher.Close()
+
+	results, err := matcher.FindOwners(diffs)
+	if err != nil {
+		return err
+	}
+
+	var resolved []owners.ResolvedOwner
+	if githubValidateOwners || actions.EnableOwnerValidation {
+		resolved, err = actions.OwnerResolver().ValidateOwners(results)
+		if err != nil {
+			return err
+		}
+		for _, owner := range resolved {
+			if !owner.Valid() {
+				fmt.Printf("::warning::Invalid owner %s\n", owner)
+			}
+		}
+	}
+
+	if githubCheckRun || actions.EnableCheckRun {
+		actions.RequireOwners = actions.RequireOwners || githubRequireOwners
+		if err := actions.WriteCheckRun(diffs, results); err != nil {
+			return err
+		}
+	}
+
+	actions.ExcludedOwners = append(actions.ExcludedOwners, githubExclude...)
+	results = actions.ExcludeOwners(results)
+
+	// The comment is written first so that owners are listed even if
+	// requesting reviews fails.
+	if err := actions.WriteComment(results); err != nil {
+		return err
+	}
+
+	// Reviews can only be requested on pull requests.
+	if (githubRequestReviews || actions.EnableReviewRequests) && actions.Target == owners.OutputPullRequest {
+		if err := actions.RequestReviews(results); err != nil {
+			return err
+		}
+	}
+
+	// Owners are still notified before failing on invalid ones.
+	return invalidOwnersError(resolved)
+}
+
+// githubDryRunRun prints the comment for the changes of the event. History
+// is not fetched, so the base and head must already exist locally.
+func githubDryRunRun(actions *owners.GitHubActions) error {
 	if actions.Draft {
+		fmt.Println("Draft pull request, no comment would be posted.")
+		return nil
+	}
+	if actions.Skip {
+		fmt.Printf("Nothing to diff for this %s event.\n", actions.EventName)
 		return nil
 	}
 
-	if err := actions.Prepare(); err != nil {
+	diffs, err := actions.Differ().Diff()
+	if err != nil {
+		return err
+	}
+
+	// Like a real run, owners files are read at the base revision unless
+	// edits to them are previewed.
+	matcher := baseMatcher(actions.BaseRef)
+	if githubWorkingTree {
+		matcher = owners.NewMatcher(ownersFileName, matcherOptions()...)
+	}
+	defer matcher.Close()
+
+	results, err := matcher.FindOwners(diffs)
+	if err != nil {
+		return err
+	}
+
+	actions.ExcludedOwners = append(actions.ExcludedOwners, githubExclude...)
+	results = actions.ExcludeOwners(results)
+
+	for i, comment := range actions.RenderComments(results) {
+		if i > 0 {
+			fmt.Println("---")
+		}
+		fmt.Print(comment)
+	}
+	return nil
+}
+
+// githubActions reads the event from --event and --event-name if set, or
+// else from the environment of the GitHub Action.
+func githubActions() (*owners.GitHubActions, error) {
+	eventPath := githubEvent
+	if eventPath == "" {
+		eventPath = os.Getenv("GITHUB_EVENT_PATH")
+	}
+	if eventPath == "" {
+		return nil, fmt.Errorf("--event or env var GITHUB_EVENT_PATH must be set")
+	}
+
+	eventName := githubEventName
+	if eventName == "" {
+		eventName = os.Getenv("GITHUB_EVENT_NAME")
+	}
+	return owners.GetGitHubActionsFromEvent(eventName, eventPath)
+}
+
+func githubStatusRun(cmd *cobra.Command, args []string) error {
+	actions, err := owners.GetGitHubActions()
+	if err != nil {
+		return err
+	}
+	if actions.Target != owners.OutputPullRequest {
+		return fmt.Errorf("approvals can only be checked for pull requests, not %s events", actions.EventName)
+	}
+
+	matcher, diffs, err := githubChanges(actions)
+	if err != nil {
 		return err
 	}
+	defer matcher.Close()
 
-	differ := owners.NewGitDiffer(actions.BaseRef, actions.HeadRef)
-	diffs, err := differ.Diff()
+	approvers, err := actions.GetApprovers()
 	if err != nil {
 		return err
 	}
 
-	results, err := owners.FindOwners(ownersFileName, diffs)
+	approvals, err := matcher.FindApprovals(diffs, approvers, actions.GetTeamMembers)
 	if err != nil {
 		return err
 	}
 
-	return actions.WriteComment(results)
+	fmt.Print(approvals.Markdown())
+
+	return actions.WriteApprovalStatus(approvals)
+}
+
+// githubChanges fetches the history of the event and returns the
+// changed files along with a matcher for owners files at the base revision.
+func githubChanges(actions *owners.GitHubActions) (*owners.Matcher, []string, error) {
+	if err := actions.Prepare(); err != nil {
+		return nil, nil, err
+	}
+
+	diffs, err := actions.Differ().Diff()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return baseMatcher(actions.BaseRef), diffs, nil
 }
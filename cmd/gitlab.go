@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var gitlabCmd = &cobra.Command{
+	Use:   "gitlab",
+	Short: "Notifies owners in a GitLab CI merge request pipeline",
+	RunE:  gitlabRun,
+}
+
+var (
+	gitlabExclude      []string
+	gitlabMaxNumOwners int
+	gitlabMaxNumFiles  int
+)
+
+func init() {
+	gitlabCmd.Flags().StringSliceVarP(&gitlabExclude, "exclude", "", nil, "owners that are never notified, e.g. bot accounts")
+	gitlabCmd.Flags().IntVarP(&gitlabMaxNumOwners, "max-num-owners", "", 0, "summarize instead of notifying owners above this number, 0 to disable")
+	gitlabCmd.Flags().IntVarP(&gitlabMaxNumFiles, "max-num-files", "", 0, "maximum number of files listed per owner, 0 to disable")
+}
+
+func gitlabRun(cmd *cobra.Command, args []string) error {
+	ci, err := owners.GetGitLabCI()
+	if err != nil {
+		return err
+	}
+	ci.ExcludedOwners = gitlabExclude
+	ci.MaxNumOwners = gitlabMaxNumOwners
+	ci.MaxNumFiles = gitlabMaxNumFiles
+
+	if err := ci.Prepare(); err != nil {
+		return err
+	}
+
+	diffs, err := ci.Differ().Diff()
+	if err != nil {
+		return err
+	}
+
+	matcher := baseMatcher(ci.BaseRef)
+	defer matcher.Close()
+
+	results, err := matcher.FindOwners(diffs)
+	if err != nil {
+		return err
+	}
+
+	return ci.WriteComment(ci.ExcludeOwners(results))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:          "lint",
+	Short:        "Lint owners files",
+	RunE:         lintRun,
+	SilenceUsage: true,
+}
+
+var (
+	lintOutputFormat string
+)
+
+func init() {
+	lintCmd.PersistentFlags().StringVarP(&lintOutputFormat, "output", "o", "text", `output format (one of "text", "json", "sarif")`)
+}
+
+func lintRun(cmd *cobra.Command, args []string) error {
+	diagnostics, err := owners.LintOwnersFiles(ownersFileName)
+	if err != nil {
+		return err
+	}
+
+	switch lintOutputFormat {
+	case "text":
+		for _, diagnostic := range diagnostics {
+			fmt.Println(diagnostic.String())
+		}
+	case "json":
+		if diagnostics == nil {
+			diagnostics = []owners.Diagnostic{}
+		}
+		data, err := json.MarshalIndent(diagnostics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "sarif":
+		if err := owners.WriteSARIF(os.Stdout, diagnostics); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format: %s", lintOutputFormat)
+	}
+
+	if owners.HasErrors(diagnostics) {
+		return fmt.Errorf("found %d problem(s) in %s files", len(diagnostics), ownersFileName)
+	}
+	return nil
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+
+	"github.com/martin-vanta/owners"
 )
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+
+		// Failing the workflow would not help until the rate limit resets.
+		if errors.Is(err, owners.ErrRateLimited) {
+			fmt.Println("API rate limit reached, soft exiting")
+			return
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var notifyCmd = &cobra.Command{
+	Use:   "notify",
+	Short: "Notifies owners on a pull request of Gitea or Bitbucket Server",
+	Long: `Notifies owners on a pull request of Gitea, Forgejo or Bitbucket Server.
+
+The token is read from GITEA_TOKEN or BITBUCKET_TOKEN.`,
+	RunE: notifyRun,
+}
+
+var (
+	notifyPlatform     string
+	notifyURL          string
+	notifyRepository   string
+	notifyPullRequest  int
+	notifyBaseRef      string
+	notifyHeadRef      string
+	notifyAuthor       string
+	notifyExclude      []string
+	notifyMaxNumOwners int
+	notifyMaxNumFiles  int
+)
+
+func init() {
+	notifyCmd.Flags().StringVarP(&notifyPlatform, "platform", "", "", "code review platform: gitea or bitbucket-server")
+	notifyCmd.Flags().StringVarP(&notifyURL, "url", "", "", "base URL of the server")
+	notifyCmd.Flags().StringVarP(&notifyRepository, "repo", "", "", "repository as owner/name, or PROJECT/repo for Bitbucket Server")
+	notifyCmd.Flags().IntVarP(&notifyPullRequest, "pr", "", 0, "pull request number")
+	notifyCmd.Flags().StringVarP(&notifyBaseRef, "base", "", "", "base revision of the pull request")
+	notifyCmd.Flags().StringVarP(&notifyHeadRef, "head", "", "HEAD", "head revision of the pull request")
+	notifyCmd.Flags().StringVarP(&notifyAuthor, "author", "", "", "author of the pull request, who is never notified")
+	notifyCmd.Flags().StringSliceVarP(&notifyExclude, "exclude", "", nil, "owners that are never notified, e.g. bot accounts")
+	notifyCmd.Flags().IntVarP(&notifyMaxNumOwners, "max-num-owners", "", 0, "summarize instead of notifying owners above this number, 0 to disable")
+	notifyCmd.Flags().IntVarP(&notifyMaxNumFiles, "max-num-files", "", 0, "maximum number of files listed per owner, 0 to disable")
+	for _, name := range []string{"platform", "url", "repo", "pr", "base"} {
+		notifyCmd.MarkFlagRequired(name)
+	}
+}
+
+func notifyRun(cmd *cobra.Command, args []string) error {
+	var notifier owners.Notifier
+	switch notifyPlatform {
+	case "gitea":
+		notifier = &owners.GiteaNotifier{
+			URL:         notifyURL,
+			Repository:  notifyRepository,
+			PullRequest: notifyPullRequest,
+			Token:       os.Getenv("GITEA_TOKEN"),
+		}
+	case "bitbucket-server":
+		notifier = &owners.BitbucketServerNotifier{
+			URL:         notifyURL,
+			Repository:  notifyRepository,
+			PullRequest: notifyPullRequest,
+			Token:       os.Getenv("BITBUCKET_TOKEN"),
+		}
+	default:
+		return fmt.Errorf("unknown platform %q", notifyPlatform)
+	}
+
+	diffs, err := owners.NewGitDiffer(notifyBaseRef, notifyHeadRef).Diff()
+	if err != nil {
+		return err
+	}
+
+	matcher := baseMatcher(notifyBaseRef)
+	defer matcher.Close()
+
+	results, err := matcher.FindOwners(diffs)
+	if err != nil {
+		return err
+	}
+
+	results = owners.ExcludeAuthor(results, notifyAuthor, notifyExclude)
+
+	return owners.WriteComments(notifier, results, owners.CommentOptions{
+		BaseRef:      notifyBaseRef,
+		HeadRef:      notifyHeadRef,
+		MaxNumOwners: notifyMaxNumOwners,
+		MaxNumFiles:  notifyMaxNumFiles,
+	})
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"runtime"
+
+	"github.com/martin-vanta/owners"
 	"github.com/spf13/cobra"
 )
 
@@ -12,14 +15,48 @@ var rootCmd = &cobra.Command{
 
 var (
 	ownersFileName string
+	ownersInherit  bool
+	parallelism    int
+	ownersCache    bool
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&ownersFileName, "owners_file_name", "", "OWNERS", "name of owners files")
+	rootCmd.PersistentFlags().BoolVarP(&ownersInherit, "inherit", "", false, "combine owners of all parent owners files instead of only the closest one")
+	rootCmd.PersistentFlags().BoolVarP(&ownersCache, "cache", "", false, "cache parsed owners files in the git directory")
+	rootCmd.PersistentFlags().IntVarP(&parallelism, "parallelism", "", runtime.NumCPU(), "number of files to match concurrently")
 
+	rootCmd.AddCommand(explainCmd)
 	rootCmd.AddCommand(findCmd)
+	rootCmd.AddCommand(fmtCmd)
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(githubCmd)
+	rootCmd.AddCommand(gitlabCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(notifyCmd)
+	rootCmd.AddCommand(validateOwnersCmd)
+}
+
+// matcherOptions returns the options of matchers set by persistent flags.
+func matcherOptions() []owners.MatcherOption {
+	opts := []owners.MatcherOption{
+		owners.WithInheritance(ownersInherit),
+		owners.WithParallelism(parallelism),
+	}
+	if ownersCache {
+		// Outside of a git repository owners files are parsed every time.
+		if cacheDir, err := owners.DefaultCacheDir(); err == nil {
+			opts = append(opts, owners.WithCache(cacheDir))
+		}
+	}
+	return opts
+}
+
+// baseMatcher returns a matcher for the owners files at baseRef. Owners
+// files are evaluated at the base revision so that a change can not change
+// the owners of its own files.
+func baseMatcher(baseRef string) *owners.Matcher {
+	return owners.NewMatcherAt(ownersFileName, baseRef, matcherOptions()...)
 }
 
 func rootRun(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/martin-vanta/owners"
+	"github.com/spf13/cobra"
+)
+
+var validateOwnersCmd = &cobra.Command{
+	Use:   "validate-owners [path]...",
+	Short: "Validates owners against GitHub",
+	Long: `Validates that the owners of files are existing GitHub users and teams
+with write access to the repository. Without paths, the owners of all files
+tracked by git are validated.
+
+Uses GITHUB_TOKEN, or a GitHub App with GITHUB_APP_ID and GITHUB_APP_PRIVATE_KEY.`,
+	RunE: validateOwnersRun,
+}
+
+var (
+	validateOwnersRepository   string
+	validateOwnersOutputFormat string
+)
+
+func init() {
+	validateOwnersCmd.Flags().StringVarP(&validateOwnersRepository, "repo", "", os.Getenv("GITHUB_REPOSITORY"), "repository as owner/name that owners need write access to")
+	validateOwnersCmd.Flags().StringVarP(&validateOwnersOutputFormat, "output", "o", "text", `output format (one of "text", "json")`)
+}
+
+func validateOwnersRun(cmd *cobra.Command, args []string) error {
+	if validateOwnersRepository == "" {
+		return fmt.Errorf("--repo or env var GITHUB_REPOSITORY must be set")
+	}
+
+	filePaths := args
+	if len(filePaths) == 0 {
+		var err error
+		filePaths, err = owners.TrackedFiles()
+		if err != nil {
+			return err
+		}
+	}
+
+	results, err := owners.FindOwners(ownersFileName, filePaths, matcherOptions()...)
+	if err != nil {
+		return err
+	}
+
+	resolver := owners.NewOwnerResolver(owners.GitHubConfigFromEnv(), validateOwnersRepository)
+	resolved, err := resolver.ValidateOwners(results)
+	if err != nil {
+		return err
+	}
+
+	switch validateOwnersOutputFormat {
+	case "text":
+		for _, owner := range resolved {
+			fmt.Println(owner.String())
+		}
+	case "json":
+		data, err := json.MarshalIndent(resolved, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		return fmt.Errorf("unknown output format: %s", validateOwnersOutputFormat)
+	}
+
+	return invalidOwnersError(resolved)
+}
+
+// invalidOwnersError returns an error if any owner is invalid.
+func invalidOwnersError(resolved []owners.ResolvedOwner) error {
+	numInvalid := 0
+	for _, owner := range resolved {
+		if !owner.Valid() {
+			numInvalid++
+		}
+	}
+	if numInvalid > 0 {
+		return fmt.Errorf("found %d invalid owners", numInvalid)
+	}
+	return nil
+}
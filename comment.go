@@ -0,0 +1,240 @@
+package owners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// Number of files listed before the rest are collapsed.
+	maxNumInlineFiles = 3
+	// Room left for the part number in the comment header.
+	commentHeaderReserve = 64
+)
+
+// commentWriter renders owners into the comment bodies posted on a change
+// by every code review platform.
+type commentWriter struct {
+	baseRef      string
+	headRef      string
+	maxNumOwners int
+	maxNumFiles  int
+	// Longest comment body accepted by the platform.
+	maxLength int
+	// First line of every comment, which finds the comments of earlier runs
+	// and is not rendered.
+	header string
+	// The platform renders markdown without HTML, so files are listed
+	// without <br> or <details>.
+	noHTML bool
+}
+
+// write renders results into one or more comment bodies that are each at
+// most maxLength long.
+func (c commentWriter) write(results FindResults) []string {
+	intro := fmt.Sprintf("[Owners](https://github.com/martin-vanta/owners): Notifying file owners for diff %s...%s.\n\n", c.baseRef, c.headRef)
+
+	w := &strings.Builder{}
+	if len(results.Owners) == 0 {
+		w.WriteString("No notifications.\n")
+		return []string{c.header + "\n" + intro + "\n" + w.String()}
+	}
+	if c.maxNumOwners > 0 && len(results.Owners) > c.maxNumOwners {
+		w.WriteString(fmt.Sprintf("Not notifying owners because the number of owners (%d) exceeds the threshold (%d).\n\n", len(results.Owners), c.maxNumOwners))
+		writeOwnersSummary(w, results, !c.noHTML)
+		return []string{c.header + "\n" + intro + "\n" + w.String()}
+	}
+
+	const tableHeader = "| Owner | Required | File(s) |\n|-|-|-|\n"
+	maxTableLength := c.maxLength - len(c.header) - len(intro) - commentHeaderReserve
+
+	var tables []string
+	table := &strings.Builder{}
+	table.WriteString(tableHeader)
+	rows := 0
+	for _, owner := range results.Owners {
+		row := c.writeRow(owner, maxTableLength-len(tableHeader))
+		if rows > 0 && table.Len()+len(row) > maxTableLength {
+			tables = append(tables, table.String())
+			table.Reset()
+			table.WriteString(tableHeader)
+			rows = 0
+		}
+		table.WriteString(row)
+		rows++
+	}
+	tables = append(tables, table.String())
+
+	var comments []string
+	for i, table := range tables {
+		header := c.header + "\n" + intro + "\n"
+		if len(tables) > 1 {
+			header = fmt.Sprintf("%s\n%s(part %d of %d)\n\n", c.header, intro, i+1, len(tables))
+		}
+		comments = append(comments, header+table)
+	}
+	return comments
+}
+
+// writeRow renders the table row of an owner, dropping files from the
+// list until the row is at most maxLength long.
+func (c commentWriter) writeRow(owner FindResult, maxLength int) string {
+	var required string
+	if !owner.Optional {
+		required = "✅"
+	}
+
+	// Backticks keep aliases from being mentioned as users.
+	name := owner.Owner
+	if len(owner.Aliases) > 0 {
+		name += " (via `" + strings.Join(owner.Aliases, "`, `") + "`)"
+	}
+
+	maxNumFiles := len(owner.FilePaths)
+	if c.maxNumFiles > 0 && c.maxNumFiles < maxNumFiles {
+		maxNumFiles = c.maxNumFiles
+	}
+	for {
+		files := formatCommentFiles(owner.FilePaths, maxNumFiles)
+		if c.noHTML {
+			files = formatPlainCommentFiles(owner.FilePaths, maxNumFiles)
+		}
+		row := fmt.Sprintf("| %s | %s | %s |\n", name, required, files)
+		if len(row) <= maxLength || maxNumFiles == 0 {
+			return row
+		}
+		maxNumFiles /= 2
+	}
+}
+
+// formatCommentFiles lists the first files inline and collapses the rest in
+// a details block. At most maxNumFiles are listed.
+func formatCommentFiles(files []string, maxNumFiles int) string {
+	shown := files
+	if len(shown) > maxNumFiles {
+		shown = shown[:maxNumFiles]
+	}
+	numHidden := len(files) - len(shown)
+
+	if len(files) <= maxNumInlineFiles && numHidden == 0 {
+		return strings.Join(files, "<br>")
+	}
+
+	inline := shown
+	if len(inline) > maxNumInlineFiles {
+		inline = inline[:maxNumInlineFiles]
+	}
+	collapsed := shown[len(inline):]
+
+	var s strings.Builder
+	s.WriteString(strings.Join(inline, "<br>"))
+	s.WriteString(fmt.Sprintf("<details><summary>+%d more</summary>", len(files)-len(inline)))
+	s.WriteString(strings.Join(collapsed, "<br>"))
+	if numHidden > 0 {
+		if len(collapsed) > 0 {
+			s.WriteString("<br>")
+		}
+		s.WriteString(fmt.Sprintf("...and %d more not shown", numHidden))
+	}
+	s.WriteString("</details>")
+	return s.String()
+}
+
+// formatPlainCommentFiles lists at most maxNumFiles files separated by
+// commas, for platforms that do not render HTML in comments.
+func formatPlainCommentFiles(files []string, maxNumFiles int) string {
+	if len(files) <= maxNumFiles {
+		return strings.Join(files, ", ")
+	}
+
+	var s strings.Builder
+	for _, file := range files[:maxNumFiles] {
+		s.WriteString(file + ", ")
+	}
+	s.WriteString(fmt.Sprintf("...and %d more not shown", len(files)-maxNumFiles))
+	return s.String()
+}
+
+const maxNumSummaryDirs = 10
+
+// writeOwnersSummary writes counts of owners per section and files per
+// top-level directory without mentioning any owner, collapsed in a details
+// block if html is set.
+func writeOwnersSummary(w *strings.Builder, results FindResults, html bool) {
+	writeLinef := func(format string, args ...interface{}) {
+		w.WriteString(fmt.Sprintf(format, args...))
+		w.WriteRune('\n')
+	}
+
+	type sectionCounts struct {
+		required int
+		optional int
+	}
+	sectionToCounts := make(map[string]*sectionCounts)
+	var sections []string
+	dirToFiles := make(map[string]map[string]bool)
+	for _, owner := range results.Owners {
+		for _, section := range owner.Sections {
+			counts, ok := sectionToCounts[section]
+			if !ok {
+				counts = &sectionCounts{}
+				sectionToCounts[section] = counts
+				sections = append(sections, section)
+			}
+			if owner.Optional {
+				counts.optional++
+			} else {
+				counts.required++
+			}
+		}
+
+		for _, filePath := range owner.FilePaths {
+			dir := "."
+			if i := strings.Index(filePath, "/"); i >= 0 {
+				dir = filePath[:i]
+			}
+			if dirToFiles[dir] == nil {
+				dirToFiles[dir] = make(map[string]bool)
+			}
+			dirToFiles[dir][filePath] = true
+		}
+	}
+	sort.Strings(sections)
+
+	var dirs []string
+	for dir := range dirToFiles {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if len(dirToFiles[dirs[i]]) != len(dirToFiles[dirs[j]]) {
+			return len(dirToFiles[dirs[i]]) > len(dirToFiles[dirs[j]])
+		}
+		return dirs[i] < dirs[j]
+	})
+
+	if html {
+		writeLinef("<details><summary>Summary of %d owners</summary>\n", len(results.Owners))
+	} else {
+		writeLinef("Summary of %d owners:\n", len(results.Owners))
+	}
+	writeLinef("| Section | Required owners | Optional owners |")
+	writeLinef("|-|-|-|")
+	for _, section := range sections {
+		counts := sectionToCounts[section]
+		writeLinef("| %s | %d | %d |", section, counts.required, counts.optional)
+	}
+	writeLinef("")
+	writeLinef("| Directory | Files |")
+	writeLinef("|-|-|")
+	for i, dir := range dirs {
+		if i == maxNumSummaryDirs {
+			writeLinef("| %d more | |", len(dirs)-maxNumSummaryDirs)
+			break
+		}
+		writeLinef("| %s | %d |", dir, len(dirToFiles[dir]))
+	}
+	if html {
+		writeLinef("\n</details>")
+	}
+}
@@ -27,12 +27,12 @@ func NewGitDiffer(baseRef, headRef string) Differ {
 
 func (d gitDiffer) Diff() ([]string, error) {
 	// Find all files changed since ancestor commit of the references.
-	stdout, err := run("git", "diff", fmt.Sprintf("%s...%s", d.baseRef, d.headRef), "--name-only")
+	stdout, err := run("git", "diff", "-z", "--name-only", fmt.Sprintf("%s...%s", d.baseRef, d.headRef))
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Fields(stdout)
+	lines := splitPaths(stdout)
 	sort.Strings(lines)
 	return lines, nil
 }
@@ -91,3 +91,40 @@ func run(command string, args ...string) (string, error) {
 	}
 	return stdout.String(), nil
 }
+
+// TrackedFiles returns the paths of all files tracked by git in the working
+// tree.
+func TrackedFiles() ([]string, error) {
+	stdout, err := run("git", "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitPaths(stdout), nil
+}
+
+// splitPaths splits the output of a git command run with -z. Paths are NUL
+// terminated and not quoted, so they may contain spaces.
+func splitPaths(stdout string) []string {
+	if stdout == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(stdout, "\x00"), "\x00")
+}
+
+// gitTreeDiffer compares the trees of two revisions without finding their
+// common ancestor, for when baseRef already is the merge base.
+type gitTreeDiffer struct {
+	baseRef string
+	headRef string
+}
+
+func (d gitTreeDiffer) Diff() ([]string, error) {
+	stdout, err := run("git", "diff", "-z", "--name-only", d.baseRef, d.headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitPaths(stdout)
+	sort.Strings(lines)
+	return lines, nil
+}
@@ -0,0 +1,31 @@
+package owners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitDiffers(t *testing.T) {
+	dir := t.TempDir()
+	gitCommand(t, dir, "init", "--quiet")
+	writeTestFile(t, dir, "a.go", "package a")
+	gitCommand(t, dir, "add", ".")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "base")
+	gitCommand(t, dir, "tag", "base")
+
+	writeTestFile(t, dir, "a.go", "package b")
+	writeTestFile(t, dir, "my dir/b.go", "package b")
+	gitCommand(t, dir, "add", ".")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "head")
+	chdir(t, dir)
+
+	for _, differ := range []Differ{
+		NewGitDiffer("base", "HEAD"),
+		gitTreeDiffer{baseRef: "base", headRef: "HEAD"},
+	} {
+		files, err := differ.Diff()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "my dir/b.go"}, files)
+	}
+}
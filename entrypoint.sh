@@ -7,5 +7,13 @@ git config --global --add safe.directory "$GITHUB_WORKSPACE"
 
 cd "$GITHUB_WORKSPACE"
 
+# Authenticate as a GitHub App if one is configured. Tracing is disabled to
+# keep the private key out of the log.
+set +x
+export GITHUB_APP_ID="${INPUT_APP_ID:-}"
+export GITHUB_APP_PRIVATE_KEY="${INPUT_APP_PRIVATE_KEY:-}"
+export GITHUB_APP_INSTALLATION_ID="${INPUT_APP_INSTALLATION_ID:-}"
+set -x
+
 echo "Running owners"
-owners github --owners_file_name="$INPUT_OWNERS_FILE_NAME"
+owners github --owners_file_name="$INPUT_OWNERS_FILE_NAME" --inherit="${INPUT_INHERIT:-false}"
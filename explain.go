@@ -0,0 +1,231 @@
+package owners
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type RuleStatus string
+
+const (
+	RuleMatched      RuleStatus = "matched"
+	RuleNotMatched   RuleStatus = "not matched"
+	RuleNotEvaluated RuleStatus = "not evaluated"
+)
+
+type Explanation struct {
+	FilePath    string          `json:"file"`
+	OwnersFiles []ExplainedFile `json:"owners_files"`
+	Owners      []MatchOwner    `json:"owners"`
+	StopReason  string          `json:"stop_reason"`
+}
+
+type ExplainedFile struct {
+	Path        string             `json:"path"`
+	Exists      bool               `json:"exists"`
+	RelFilePath string             `json:"relative_file"`
+	Sections    []ExplainedSection `json:"sections"`
+	Owners      []MatchOwner       `json:"owners"`
+}
+
+type ExplainedSection struct {
+	Name     string          `json:"name"`
+	Line     int             `json:"line"`
+	Optional bool            `json:"optional"`
+	Rules    []ExplainedRule `json:"rules"`
+	Matched  bool            `json:"matched"`
+	// Whether the matched rule is negated, so the section has no owners.
+	Excluded          bool     `json:"excluded"`
+	UsedDefaultOwners bool     `json:"used_default_owners"`
+	Owners            []string `json:"owners"`
+}
+
+type ExplainedRule struct {
+	Pattern string     `json:"pattern"`
+	Negated bool       `json:"negated"`
+	Owners  []string   `json:"owners"`
+	Line    int        `json:"line"`
+	Status  RuleStatus `json:"status"`
+}
+
+// Explain walks the same owners files as Match and records how each of them
+// was evaluated for filePath, along with why the walk stopped.
+func (m *Matcher) Explain(filePath string) (*Explanation, error) {
+	explanation := &Explanation{FilePath: filePath}
+	var allSectionMatches []SectionMatch
+	inherited := false
+
+	parts := strings.Split(filepath.Clean(filePath), string(os.PathSeparator))
+	for i := len(parts) - 1; i >= 0; i-- {
+		dirPath := filepath.Join(parts[:i]...)
+		ownersFile, err := m.Load(dirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		relFilePath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		aliases, err := m.loadAliases(dirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		index := m.index(ownersFile)
+		explainedFile, err := explainFile(ownersFile, index, aliases, relFilePath)
+		if err != nil {
+			return nil, err
+		}
+		explainedFile.Path = filepath.Join(dirPath, m.ownersFileName)
+		explanation.OwnersFiles = append(explanation.OwnersFiles, *explainedFile)
+
+		sectionMatches, excluded, err := matchSectionsInFile(ownersFile, index, aliases, relFilePath)
+		if err != nil {
+			return nil, err
+		}
+		allSectionMatches = append(allSectionMatches, sectionMatches...)
+		explanation.Owners = matchOwners(allSectionMatches)
+
+		matched := len(sectionMatches) > 0 || excluded
+		if m.stopsSearch(ownersFile, matched, inherited) {
+			switch {
+			case ownersFile.HasSetting(SettingNoParent):
+				explanation.StopReason = fmt.Sprintf("%s sets noparent, parent directories are not searched", explainedFile.Path)
+			case len(sectionMatches) == 0:
+				explanation.StopReason = fmt.Sprintf("%s excluded the file, parent directories are not searched", explainedFile.Path)
+			default:
+				explanation.StopReason = fmt.Sprintf("%s matched owners, parent directories are not searched", explainedFile.Path)
+			}
+			return explanation, nil
+		}
+		inherited = inherited || (matched && ownersFile.HasSetting(SettingInherit))
+	}
+
+	if len(explanation.Owners) > 0 {
+		explanation.StopReason = "reached the root directory, owners of all matching files are combined"
+	} else {
+		explanation.StopReason = "reached the root directory without matching owners"
+	}
+	return explanation, nil
+}
+
+func explainFile(ownersFile *OwnersFile, index *fileIndex, aliases map[string][]string, relFilePath string) (*ExplainedFile, error) {
+	explainedFile := &ExplainedFile{
+		Exists:      ownersFile.Path != "",
+		RelFilePath: relFilePath,
+	}
+
+	for i, section := range ownersFile.Sections {
+		ruleIndex, err := index.sections[i].match(relFilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		explainedSection := ExplainedSection{
+			Name:     section.Name,
+			Line:     section.Line,
+			Optional: section.Optional,
+		}
+		for i, rule := range section.Rules {
+			status := RuleNotMatched
+			if ruleIndex >= 0 && i < ruleIndex {
+				status = RuleNotEvaluated
+			} else if i == ruleIndex {
+				status = RuleMatched
+			}
+			explainedSection.Rules = append(explainedSection.Rules, ExplainedRule{
+				Pattern: rule.Pattern,
+				Negated: rule.Negated,
+				Owners:  rule.Owners,
+				Line:    rule.Line,
+				Status:  status,
+			})
+		}
+		if ruleIndex >= 0 {
+			rule := section.Rules[ruleIndex]
+			explainedSection.Matched = true
+			explainedSection.Excluded = rule.Negated
+			explainedSection.UsedDefaultOwners = !rule.Negated && len(rule.Owners) == 0
+			explainedSection.Owners, _ = expandAliases(section.ruleOwners(rule), aliases)
+		}
+		explainedFile.Sections = append(explainedFile.Sections, explainedSection)
+	}
+
+	owners, err := matchInFile(ownersFile, index, aliases, relFilePath)
+	if err != nil {
+		return nil, err
+	}
+	explainedFile.Owners = owners
+
+	return explainedFile, nil
+}
+
+func (e *Explanation) String() string {
+	var s strings.Builder
+
+	writeLinef := func(indentLevel int, format string, args ...interface{}) {
+		for i := 0; i < indentLevel; i++ {
+			s.WriteString("  ")
+		}
+		s.WriteString(fmt.Sprintf(format, args...))
+		s.WriteRune('\n')
+	}
+
+	writeLinef(0, "%s:", e.FilePath)
+	for _, file := range e.OwnersFiles {
+		if !file.Exists {
+			writeLinef(1, "%s: not found", file.Path)
+			continue
+		}
+
+		writeLinef(1, "%s: matching %s", file.Path, file.RelFilePath)
+		for _, section := range file.Sections {
+			if section.Line == 0 && len(section.Rules) == 0 {
+				continue
+			}
+
+			var optional string
+			if section.Optional {
+				optional = " (optional)"
+			}
+			writeLinef(2, "[%s]%s:", section.Name, optional)
+			for _, rule := range section.Rules {
+				pattern := rule.Pattern
+				if rule.Negated {
+					pattern = "!" + pattern
+				}
+				ruleText := strings.Join(append([]string{pattern}, rule.Owners...), " ")
+				writeLinef(3, "%s:%d: %s: %s", file.Path, rule.Line, ruleText, rule.Status)
+			}
+
+			switch {
+			case !section.Matched:
+				writeLinef(3, "=> no match")
+			case section.Excluded:
+				writeLinef(3, "=> excluded, no owners")
+			case len(section.Owners) == 0:
+				writeLinef(3, "=> no owners")
+			case section.UsedDefaultOwners:
+				writeLinef(3, "=> %s (section default owners)", strings.Join(section.Owners, " "))
+			default:
+				writeLinef(3, "=> %s", strings.Join(section.Owners, " "))
+			}
+		}
+	}
+
+	writeLinef(1, "stopped: %s", e.StopReason)
+	writeLinef(1, "owners:")
+	for _, owner := range e.Owners {
+		var optional string
+		if owner.Optional {
+			optional = " (optional)"
+		}
+		writeLinef(2, "%s%s%s", owner.Owner, optional, formatAliases(owner.Aliases))
+	}
+
+	return s.String()
+}
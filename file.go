@@ -18,58 +18,155 @@ const (
 )
 
 type OwnersFile struct {
+	Path string
+	// Settings such as set noparent, which change how the file is matched.
+	Settings []*Setting
+	// Aliases defined in the file, which apply to the file and to owners
+	// files in subdirectories.
+	Aliases  []*Alias
 	Sections []*Section
+	// Comment lines after the last section header or rule.
+	TrailingComments []string
+}
+
+// Source records where a section header or rule was defined.
+type Source struct {
+	// Line number starting at 1, 0 for the implicit default section.
+	Line int
+	// Line with surrounding whitespace trimmed.
+	Raw string
+	// Comment on the same line, including the leading #.
+	Comment string
+	// Comment lines since the previous section header or rule, including the
+	// leading #. Blank lines after the first comment are kept as empty
+	// strings, so a comment block separated from the line by a blank line
+	// ends with one.
+	Doc []string
 }
 
 type Section struct {
 	Name     string
 	Optional bool
-	// Number of required approvals, not useful for GitHub.
+	// Number of approvals required from the owners of a file.
 	Approvals     int
 	DefaultOwners []string
 	Rules         []*Rule
+	Source
+}
+
+const (
+	// SettingInherit combines the owners of the file with those of parent
+	// directories.
+	SettingInherit = "inherit"
+	// SettingNoParent ignores owners files of parent directories.
+	SettingNoParent = "noparent"
+)
+
+// Setting is a set directive, e.g. set noparent.
+type Setting struct {
+	Name string
+	Source
+}
+
+// HasSetting reports whether the file sets the setting name.
+func (f *OwnersFile) HasSetting(name string) bool {
+	for _, setting := range f.Settings {
+		if setting.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Alias names a group of owners, e.g. @alias backend = @alice @org/backend.
+// Rules and section headers reference it as @backend.
+type Alias struct {
+	Name   string
+	Owners []string
+	Source
 }
 
 type Rule struct {
 	Pattern string
+	// Whether the rule was written as !pattern. Files matched by a negated
+	// rule have no owners in its section.
+	Negated bool
 	Owners  []string
+	Source
 }
 
-func ParseFile(r io.Reader) (*OwnersFile, error) {
-	file := &OwnersFile{}
+func ParseFile(path string, r io.Reader) (*OwnersFile, error) {
+	file := &OwnersFile{Path: path}
 
 	currSection := &Section{Name: defaultSectionName, Approvals: 1}
 	file.Sections = append(file.Sections, currSection)
 
+	var doc []string
+	lineNum := 0
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
+		lineNum++
+		raw := strings.TrimSpace(scanner.Text())
 
-		// Strip comments and whitespace.
-		commentSplit := strings.SplitN(line, "#", 2)
-		line = strings.TrimSpace(commentSplit[0])
+		// Split off comments and whitespace.
+		commentSplit := strings.SplitN(raw, "#", 2)
+		line := strings.TrimSpace(commentSplit[0])
 
 		if line == "" {
+			if len(commentSplit) == 2 {
+				doc = append(doc, raw)
+			} else if len(doc) > 0 {
+				doc = append(doc, "")
+			}
+			continue
+		}
+
+		source := Source{Line: lineNum, Raw: raw, Doc: doc}
+		if len(commentSplit) == 2 {
+			source.Comment = "#" + commentSplit[1]
+		}
+		doc = nil
+
+		if matches := settingRe.FindStringSubmatch(line); len(matches) > 0 {
+			file.Settings = append(file.Settings, &Setting{Name: matches[1], Source: source})
+			continue
+		}
+
+		if alias := parseAlias(line); alias != nil {
+			alias.Source = source
+			file.Aliases = append(file.Aliases, alias)
 			continue
 		}
 
 		// Try to parse a section header, otherwise parse line as a rule.
 		section := parseSectionHeader(line)
 		if section != nil {
+			section.Source = source
 			file.Sections = append(file.Sections, section)
 			currSection = section
 			continue
 		}
 
-		currSection.Rules = append(currSection.Rules, parseRule(line))
+		rule := parseRule(line)
+		rule.Source = source
+		currSection.Rules = append(currSection.Rules, rule)
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
 
+	file.TrailingComments = trimBlankLines(doc)
+
 	return file, nil
 }
 
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
 var (
 	// e.g. ^[Documentation][2] @docs-team
 	sectionHeaderRe = regexp.MustCompile(strings.Join([]string{
@@ -77,11 +174,28 @@ var (
 		`(?P<optional>\^)?`,
 		`\[(?P<name>\w+)\]`,
 		`(\[(?P<approvals>-?\d+)\])?`,
-		`(?P<default_owners>(\s@\w+(/\w+)?)*)`,
+		`(?P<default_owners>(\s+\S+)*)`,
 		`$`,
 	}, ""))
+
+	// e.g. set noparent
+	settingRe = regexp.MustCompile(`^set\s+(\w+)$`)
+
+	// e.g. @alias backend = @alice @org/backend
+	aliasRe = regexp.MustCompile(`^@alias\s+(\w[\w-]*)\s*=(.*)$`)
 )
 
+func parseAlias(line string) *Alias {
+	matches := aliasRe.FindStringSubmatch(line)
+	if len(matches) == 0 {
+		return nil
+	}
+	return &Alias{
+		Name:   matches[1],
+		Owners: tokenize(matches[2]),
+	}
+}
+
 func parseSectionHeader(line string) *Section {
 	matches := sectionHeaderRe.FindStringSubmatch(line)
 	if len(matches) == 0 {
@@ -107,16 +221,24 @@ func parseSectionHeader(line string) *Section {
 			}
 			section.Approvals = int(approvals)
 		case "default_owners":
-			section.DefaultOwners = strings.Fields(match)
+			section.DefaultOwners = tokenize(match)
+			// A header with anything but owners after it is not a header.
+			for _, owner := range section.DefaultOwners {
+				if _, err := ParseOwner(owner); err != nil {
+					return nil
+				}
+			}
 		}
 	}
 	return section
 }
 
 func parseRule(line string) *Rule {
-	parts := strings.Fields(line)
+	parts := tokenize(line)
+	pattern, negated := strings.CutPrefix(parts[0], "!")
 	return &Rule{
-		Pattern: normalizePattern(parts[0]),
+		Pattern: normalizePattern(pattern),
+		Negated: negated,
 		Owners:  parts[1:],
 	}
 }
@@ -55,6 +55,12 @@ func TestParseSectionHeader(t *testing.T) {
 			line:     "^[name][2] @user1 @org/user2",
 			expected: &Section{Name: "name", Optional: true, DefaultOwners: []string{"@user1", "@org/user2"}, Approvals: 2},
 		},
+		{
+			line:     "[name] @jane.doe @org/platform-team jane@example.com @@maintainer",
+			expected: &Section{Name: "name", DefaultOwners: []string{"@jane.doe", "@org/platform-team", "jane@example.com", "@@maintainer"}, Approvals: 1},
+		},
+		{line: "[name] @user1 not-an-owner", expected: nil},
+		{line: "[name]@user1", expected: nil},
 	}
 	for _, test := range tests {
 		got := parseSectionHeader(test.line)
@@ -67,18 +73,25 @@ func TestParseFile(t *testing.T) {
 		contents string
 		expected *OwnersFile
 	}{
-		{contents: "", expected: &OwnersFile{Sections: []*Section{{Name: defaultSectionName, Approvals: 1}}}},
-		{contents: "  ", expected: &OwnersFile{Sections: []*Section{{Name: defaultSectionName, Approvals: 1}}}},
-		{contents: "  # foo", expected: &OwnersFile{Sections: []*Section{{Name: defaultSectionName, Approvals: 1}}}},
+		{contents: "", expected: &OwnersFile{Path: "OWNERS", Sections: []*Section{{Name: defaultSectionName, Approvals: 1}}}},
+		{contents: "  ", expected: &OwnersFile{Path: "OWNERS", Sections: []*Section{{Name: defaultSectionName, Approvals: 1}}}},
+		{
+			contents: "  # foo",
+			expected: &OwnersFile{
+				Path:             "OWNERS",
+				Sections:         []*Section{{Name: defaultSectionName, Approvals: 1}},
+				TrailingComments: []string{"# foo"},
+			},
+		},
 		{
 			contents: `
 				foo.go @user1
 				bar.ts @user2
 			`,
-			expected: &OwnersFile{Sections: []*Section{
+			expected: &OwnersFile{Path: "OWNERS", Sections: []*Section{
 				{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
-					{Pattern: "foo.go", Owners: []string{"@user1"}},
-					{Pattern: "bar.ts", Owners: []string{"@user2"}},
+					{Pattern: "foo.go", Owners: []string{"@user1"}, Source: Source{Line: 2, Raw: "foo.go @user1"}},
+					{Pattern: "bar.ts", Owners: []string{"@user2"}, Source: Source{Line: 3, Raw: "bar.ts @user2"}},
 				}},
 			}},
 		},
@@ -89,19 +102,92 @@ func TestParseFile(t *testing.T) {
 				^[docs] @docs
 				readme.md
 			`,
-			expected: &OwnersFile{Sections: []*Section{
+			expected: &OwnersFile{Path: "OWNERS", Sections: []*Section{
 				{Name: defaultSectionName, Approvals: 1},
-				{Name: "go", Approvals: 1, Rules: []*Rule{
-					{Pattern: "foo.go", Owners: []string{"@user1"}},
+				{Name: "go", Approvals: 1, Source: Source{Line: 2, Raw: "[go]"}, Rules: []*Rule{
+					{Pattern: "foo.go", Owners: []string{"@user1"}, Source: Source{Line: 3, Raw: "foo.go @user1"}},
+				}},
+				{Name: "docs", Optional: true, Approvals: 1, DefaultOwners: []string{"@docs"}, Source: Source{Line: 4, Raw: "^[docs] @docs"}, Rules: []*Rule{
+					{Pattern: "readme.md", Owners: []string{}, Source: Source{Line: 5, Raw: "readme.md"}},
 				}},
-				{Name: "docs", Optional: true, Approvals: 1, DefaultOwners: []string{"@docs"}, Rules: []*Rule{
-					{Pattern: "readme.md", Owners: []string{}},
+			}},
+		},
+		{
+			contents: `# Header comment.
+
+				# Go files.
+				foo.go @user1 # Trailing comment.
+
+				# Docs.
+				#
+				# More docs.
+
+				[docs] @docs # Section comment.
+				readme.md
+
+				# Trailing.
+			`,
+			expected: &OwnersFile{
+				Path: "OWNERS",
+				Sections: []*Section{
+					{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
+						{
+							Pattern: "foo.go",
+							Owners:  []string{"@user1"},
+							Source: Source{
+								Line:    4,
+								Raw:     "foo.go @user1 # Trailing comment.",
+								Comment: "# Trailing comment.",
+								Doc:     []string{"# Header comment.", "", "# Go files."},
+							},
+						},
+					}},
+					{
+						Name:          "docs",
+						Approvals:     1,
+						DefaultOwners: []string{"@docs"},
+						Source: Source{
+							Line:    10,
+							Raw:     "[docs] @docs # Section comment.",
+							Comment: "# Section comment.",
+							Doc:     []string{"# Docs.", "#", "# More docs.", ""},
+						},
+						Rules: []*Rule{
+							{Pattern: "readme.md", Owners: []string{}, Source: Source{Line: 11, Raw: "readme.md"}},
+						},
+					},
+				},
+				TrailingComments: []string{"# Trailing."},
+			},
+		},
+		{
+			contents: `
+				@alias backend = @alice @org/backend
+				foo.go @backend
+			`,
+			expected: &OwnersFile{
+				Path: "OWNERS",
+				Aliases: []*Alias{
+					{Name: "backend", Owners: []string{"@alice", "@org/backend"}, Source: Source{Line: 2, Raw: "@alias backend = @alice @org/backend"}},
+				},
+				Sections: []*Section{
+					{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
+						{Pattern: "foo.go", Owners: []string{"@backend"}, Source: Source{Line: 3, Raw: "foo.go @backend"}},
+					}},
+				},
+			},
+		},
+		{
+			contents: "!/gen/**",
+			expected: &OwnersFile{Path: "OWNERS", Sections: []*Section{
+				{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
+					{Pattern: "gen/**", Negated: true, Owners: []string{}, Source: Source{Line: 1, Raw: "!/gen/**"}},
 				}},
 			}},
 		},
 	}
 	for _, test := range tests {
-		got, err := ParseFile(bytes.NewBufferString(test.contents))
+		got, err := ParseFile("OWNERS", bytes.NewBufferString(test.contents))
 		assert.NoError(t, err)
 		assert.Equal(t, test.expected, got, "contents:\n%s", test.contents)
 	}
@@ -4,28 +4,55 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
-func FindOwners(ownersFileName string, filePaths []string) (FindResults, error) {
-	ownerToFiles := make(map[MatchOwner][]string)
-	matcher := NewMatcher(ownersFileName)
-	for _, filePath := range filePaths {
-		matchedOwners, err := matcher.Match(filePath)
-		if err != nil {
-			return FindResults{}, err
-		}
+func FindOwners(ownersFileName string, filePaths []string, opts ...MatcherOption) (FindResults, error) {
+	return NewMatcher(ownersFileName, opts...).FindOwners(filePaths)
+}
+
+// FindOwners groups filePaths by their owners. Files are matched
+// concurrently if the matcher was created WithParallelism.
+func (m *Matcher) FindOwners(filePaths []string) (FindResults, error) {
+	type ownerKey struct {
+		owner    string
+		optional bool
+	}
+	ownerToFiles := make(map[ownerKey][]string)
+	ownerToSections := make(map[ownerKey][]string)
+	ownerToAliases := make(map[ownerKey][]string)
 
-		for _, matchedOwner := range matchedOwners {
-			ownerToFiles[matchedOwner] = append(ownerToFiles[matchedOwner], filePath)
+	fileMatches, err := m.matchAll(filePaths)
+	if err != nil {
+		return FindResults{}, err
+	}
+	for i, filePath := range filePaths {
+		for _, matchedOwner := range fileMatches[i] {
+			key := ownerKey{owner: matchedOwner.Owner, optional: matchedOwner.Optional}
+			ownerToFiles[key] = append(ownerToFiles[key], filePath)
+			for _, section := range matchedOwner.Sections {
+				ownerToSections[key] = appendUnique(ownerToSections[key], section)
+			}
+			for _, alias := range matchedOwner.Aliases {
+				ownerToAliases[key] = appendUnique(ownerToAliases[key], alias)
+			}
 		}
 	}
 
 	var results FindResults
-	for matchedOwner, filePaths := range ownerToFiles {
+	for key, filePaths := range ownerToFiles {
 		sort.Strings(filePaths)
+		sections := ownerToSections[key]
+		sort.Strings(sections)
+		aliases := ownerToAliases[key]
+		sort.Strings(aliases)
 		results.Owners = append(results.Owners, FindResult{
-			Owner:     matchedOwner.Owner,
-			Optional:  matchedOwner.Optional,
+			Owner:     key.owner,
+			Type:      ownerType(key.owner),
+			Optional:  key.optional,
+			Sections:  sections,
+			Aliases:   aliases,
 			FilePaths: filePaths,
 		})
 	}
@@ -40,16 +67,99 @@ func FindOwners(ownersFileName string, filePaths []string) (FindResults, error)
 	return results, nil
 }
 
+// matchAll matches every file, on as many goroutines as the parallelism of
+// the matcher. The owners of each file are at the index of the file.
+func (m *Matcher) matchAll(filePaths []string) ([][]MatchOwner, error) {
+	fileMatches := make([][]MatchOwner, len(filePaths))
+	if m.parallelism < 2 {
+		for i, filePath := range filePaths {
+			matchedOwners, err := m.Match(filePath)
+			if err != nil {
+				return nil, err
+			}
+			fileMatches[i] = matchedOwners
+		}
+		return fileMatches, nil
+	}
+
+	var (
+		next     atomic.Int64
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < m.parallelism && w < len(filePaths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= len(filePaths) {
+					return
+				}
+				matchedOwners, err := m.Match(filePaths[i])
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					failed.Store(true)
+					return
+				}
+				fileMatches[i] = matchedOwners
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return fileMatches, nil
+}
+
 type FindResults struct {
 	Owners []FindResult `json:"owners"`
 }
 
 type FindResult struct {
-	Owner     string   `json:"owner"`
-	Optional  bool     `json:"optional"`
+	Owner    string    `json:"owner"`
+	Type     OwnerType `json:"type,omitempty"`
+	Optional bool      `json:"optional"`
+	Sections []string  `json:"sections"`
+	// Aliases that the owner was expanded from.
+	Aliases   []string `json:"aliases,omitempty"`
 	FilePaths []string `json:"files"`
 }
 
+// Exclude returns results without the given owners. Owners are compared case
+// insensitively and the leading @ is optional, so GitHub logins can be used.
+func (r FindResults) Exclude(owners []string) FindResults {
+	excluded := make(map[string]bool)
+	for _, owner := range owners {
+		excluded[normalizeHandle(owner)] = true
+	}
+
+	var results FindResults
+	for _, result := range r.Owners {
+		if !excluded[normalizeHandle(result.Owner)] {
+			results.Owners = append(results.Owners, result)
+		}
+	}
+	return results
+}
+
+// formatAliases describes the aliases an owner was expanded from, e.g.
+// " (via @backend)".
+func formatAliases(aliases []string) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+	return " (via " + strings.Join(aliases, ", ") + ")"
+}
+
+func normalizeHandle(owner string) string {
+	return strings.ToLower(strings.TrimPrefix(owner, "@"))
+}
+
 func (r FindResults) String() string {
 	var s strings.Builder
 
@@ -68,7 +178,7 @@ func (r FindResults) String() string {
 			optional = " (optional)"
 		}
 
-		writeLinef(1, "%s%s:", result.Owner, optional)
+		writeLinef(1, "%s%s%s:", result.Owner, optional, formatAliases(result.Aliases))
 		for _, filePath := range result.FilePaths {
 			writeLinef(2, filePath)
 		}
@@ -0,0 +1,22 @@
+package owners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindResultsExclude(t *testing.T) {
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@Bob", Optional: true, FilePaths: []string{"b.go"}},
+		{Owner: "@dependabot", FilePaths: []string{"go.mod"}},
+		{Owner: "@org/team", FilePaths: []string{"a.go"}},
+	}}
+
+	assert.Equal(t, results, results.Exclude(nil))
+	assert.Equal(t, FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@org/team", FilePaths: []string{"a.go"}},
+	}}, results.Exclude([]string{"bob", "@dependabot", "@carol"}))
+}
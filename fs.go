@@ -1,48 +1,396 @@
 package owners
 
-// import (
-// 	"bytes"
-// 	"errors"
-// 	"os"
-// 	"os/exec"
-// )
-
-// type FS interface {
-// 	Open(name string) (File, error)
-// }
-
-// type File interface {
-// 	Stat() (os.FileInfo, error)
-// 	Read([]byte) (int, error)
-// 	Close() error
-// }
-
-// // memfile is an in-memory file
-// type memfile struct {
-// 	*bytes.Buffer
-// }
-
-// func (m memfile) Close() error {
-// 	return nil
-// }
-
-// func (m memfile) Stat() (os.FileInfo, error) {
-// 	return nil, errors.New("memfile does not support stat")
-// }
-
-// // gitfs implements the FS interface for files at a specific git revision.
-// type gitfs struct {
-// 	cwd string
-// 	rev string
-// }
-
-// func (g *gitfs) Open(name string) (File, error) {
-// 	cmd := exec.Command("git", "-C", g.cwd, "show", g.rev+":"+name)
-// 	buf, err := cmd.Output()
-// 	if err != nil {
-// 		return nil, os.ErrNotExist
-// 	}
-// 	return memfile{
-// 		Buffer: bytes.NewBuffer(buf),
-// 	}, nil
-// }
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// gitFs is a read-only afero.Fs for the tree of a git revision. Objects are
+// read through a single long running git cat-file --batch process.
+type gitFs struct {
+	// Working directory of git commands, empty for the current directory.
+	dir string
+	rev string
+
+	startOnce sync.Once
+	startErr  error
+	treeID    string
+	prefix    string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+var _ afero.Fs = (*gitFs)(nil)
+
+func newGitFs(dir, rev string) *gitFs {
+	return &gitFs{dir: dir, rev: rev}
+}
+
+func (g *gitFs) start() error {
+	g.startOnce.Do(func() {
+		treeID, err := run("git", "-C", g.dir, "rev-parse", "--verify", "--quiet", g.rev+"^{tree}")
+		if err != nil {
+			g.startErr = fmt.Errorf("unknown git revision %s", g.rev)
+			return
+		}
+		g.treeID = strings.TrimSpace(treeID)
+
+		// Paths are relative to the working directory, object names to the repository root.
+		prefix, err := run("git", "-C", g.dir, "rev-parse", "--show-prefix")
+		if err != nil {
+			g.startErr = err
+			return
+		}
+		g.prefix = strings.TrimSpace(prefix)
+
+		cmd := exec.Command("git", "-C", g.dir, "cat-file", "--batch")
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			g.startErr = err
+			return
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			g.startErr = err
+			return
+		}
+		if err := cmd.Start(); err != nil {
+			g.startErr = fmt.Errorf("error starting git cat-file: %w", err)
+			return
+		}
+
+		g.cmd = cmd
+		g.stdin = stdin
+		g.stdout = bufio.NewReader(stdout)
+	})
+	return g.startErr
+}
+
+func (g *gitFs) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cmd == nil {
+		return nil
+	}
+	g.stdin.Close()
+	err := g.cmd.Wait()
+	g.cmd = nil
+	return err
+}
+
+type gitObject struct {
+	id   string
+	typ  string
+	data []byte
+}
+
+func (g *gitFs) objectPath(name string) string {
+	name = filepath.ToSlash(filepath.Clean(name))
+	name = path.Join(g.prefix, strings.TrimLeft(name, "/"))
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+func (g *gitFs) readObject(op, name string) (*gitObject, error) {
+	if err := g.start(); err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.cmd == nil {
+		return nil, &os.PathError{Op: op, Path: name, Err: afero.ErrFileClosed}
+	}
+
+	if _, err := fmt.Fprintf(g.stdin, "%s:%s\n", g.treeID, g.objectPath(name)); err != nil {
+		return nil, err
+	}
+
+	header, err := g.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("error reading git cat-file output: %w", err)
+	}
+	// Missing objects are reported as "<object> missing", where the object
+	// name may contain spaces.
+	if strings.HasSuffix(strings.TrimSuffix(header, "\n"), " missing") {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected git cat-file output for %s: %s", name, header)
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected git cat-file output for %s: %s", name, header)
+	}
+	// Contents are followed by a newline.
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(g.stdout, data); err != nil {
+		return nil, fmt.Errorf("error reading git cat-file output: %w", err)
+	}
+
+	return &gitObject{id: fields[0], typ: fields[1], data: data[:size]}, nil
+}
+
+func (g *gitFs) Name() string {
+	return "gitFs"
+}
+
+func (g *gitFs) Open(name string) (afero.File, error) {
+	object, err := g.readObject("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &gitFile{
+		name:   name,
+		info:   newGitFileInfo(path.Base(filepath.ToSlash(name)), object),
+		reader: bytes.NewReader(object.data),
+	}
+	if object.typ == "tree" {
+		file.entries, err = parseTree(object)
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: name, Err: err}
+		}
+		file.reader = bytes.NewReader(nil)
+	}
+	return file, nil
+}
+
+func (g *gitFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EPERM}
+	}
+	return g.Open(name)
+}
+
+func (g *gitFs) Stat(name string) (os.FileInfo, error) {
+	object, err := g.readObject("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return newGitFileInfo(path.Base(filepath.ToSlash(name)), object), nil
+}
+
+func (g *gitFs) Create(name string) (afero.File, error) {
+	return nil, &os.PathError{Op: "create", Path: name, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Mkdir(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EPERM}
+}
+
+func (g *gitFs) MkdirAll(path string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: path, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: syscall.EPERM}
+}
+
+func (g *gitFs) RemoveAll(path string) error {
+	return &os.PathError{Op: "remove", Path: path, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Chmod(name string, mode os.FileMode) error {
+	return &os.PathError{Op: "chmod", Path: name, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Chown(name string, uid, gid int) error {
+	return &os.PathError{Op: "chown", Path: name, Err: syscall.EPERM}
+}
+
+func (g *gitFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	return &os.PathError{Op: "chtimes", Path: name, Err: syscall.EPERM}
+}
+
+type gitFileInfo struct {
+	name string
+	size int64
+	mode os.FileMode
+}
+
+func newGitFileInfo(name string, object *gitObject) *gitFileInfo {
+	info := &gitFileInfo{name: name, size: int64(len(object.data)), mode: 0444}
+	if object.typ == "tree" {
+		info.mode = os.ModeDir | 0555
+	}
+	return info
+}
+
+func (i *gitFileInfo) Name() string       { return i.name }
+func (i *gitFileInfo) Size() int64        { return i.size }
+func (i *gitFileInfo) Mode() os.FileMode  { return i.mode }
+func (i *gitFileInfo) ModTime() time.Time { return time.Time{} }
+func (i *gitFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *gitFileInfo) Sys() interface{}   { return nil }
+
+// parseTree decodes the entries of a tree object. Each entry is
+// "<mode> <name>\0<binary object id>". Sizes of entries are not known
+// without reading every object and are reported as 0.
+func parseTree(object *gitObject) ([]os.FileInfo, error) {
+	idLen := len(object.id) / 2
+
+	var entries []os.FileInfo
+	data := object.data
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		null := bytes.IndexByte(data, 0)
+		if space < 0 || null < space || len(data) < null+1+idLen {
+			return nil, errors.New("malformed git tree object")
+		}
+
+		mode, err := strconv.ParseUint(string(data[:space]), 8, 32)
+		if err != nil {
+			return nil, errors.New("malformed git tree object")
+		}
+		info := &gitFileInfo{name: string(data[space+1 : null]), mode: 0444}
+		switch mode & 0170000 {
+		case 0040000, 0160000:
+			// Directories and submodules.
+			info.mode = os.ModeDir | 0555
+		case 0120000:
+			info.mode = os.ModeSymlink | 0444
+		}
+		entries = append(entries, info)
+
+		data = data[null+1+idLen:]
+	}
+	return entries, nil
+}
+
+type gitFile struct {
+	name    string
+	info    *gitFileInfo
+	reader  *bytes.Reader
+	entries []os.FileInfo
+	closed  bool
+}
+
+var _ afero.File = (*gitFile)(nil)
+
+func (f *gitFile) checkClosed(op string) error {
+	if f.closed {
+		return &os.PathError{Op: op, Path: f.name, Err: afero.ErrFileClosed}
+	}
+	return nil
+}
+
+func (f *gitFile) Close() error {
+	if err := f.checkClosed("close"); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *gitFile) Read(p []byte) (int, error) {
+	if err := f.checkClosed("read"); err != nil {
+		return 0, err
+	}
+	return f.reader.Read(p)
+}
+
+func (f *gitFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.checkClosed("read"); err != nil {
+		return 0, err
+	}
+	return f.reader.ReadAt(p, off)
+}
+
+func (f *gitFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.checkClosed("seek"); err != nil {
+		return 0, err
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *gitFile) Write(p []byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *gitFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *gitFile) WriteString(s string) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *gitFile) Truncate(size int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: syscall.EPERM}
+}
+
+func (f *gitFile) Sync() error {
+	return nil
+}
+
+func (f *gitFile) Name() string {
+	return f.name
+}
+
+func (f *gitFile) Stat() (os.FileInfo, error) {
+	if err := f.checkClosed("stat"); err != nil {
+		return nil, err
+	}
+	return f.info, nil
+}
+
+func (f *gitFile) Readdir(count int) ([]os.FileInfo, error) {
+	if err := f.checkClosed("readdir"); err != nil {
+		return nil, err
+	}
+	if !f.info.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+func (f *gitFile) Readdirnames(n int) ([]string, error) {
+	entries, err := f.Readdir(n)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, err
+}
@@ -0,0 +1,120 @@
+package owners
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gitCommand(t *testing.T, dir string, args ...string) string {
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, "git %v:\n%s", args, out)
+	return string(out)
+}
+
+func writeTestFile(t *testing.T, dir, name, contents string) {
+	filePath := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0755))
+	require.NoError(t, os.WriteFile(filePath, []byte(contents), 0644))
+}
+
+// chdir changes the working directory to dir until the test ends.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func TestGitFs(t *testing.T) {
+	dir := t.TempDir()
+	gitCommand(t, dir, "init", "--quiet")
+	writeTestFile(t, dir, "OWNERS", "** @base")
+	writeTestFile(t, dir, "a/OWNERS", "a.go @a_base")
+	writeTestFile(t, dir, "a/b/c.go", "package b")
+	writeTestFile(t, dir, "my dir/OWNERS", "** @space_base")
+	gitCommand(t, dir, "add", ".")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "base")
+	gitCommand(t, dir, "tag", "base")
+
+	// Changes after the base revision are not visible.
+	writeTestFile(t, dir, "OWNERS", "** @head")
+	writeTestFile(t, dir, "x/OWNERS", "** @head")
+	gitCommand(t, dir, "add", ".")
+	gitCommand(t, dir, "commit", "--quiet", "-m", "head")
+
+	fs := newGitFs(dir, "base")
+	defer fs.Close()
+
+	file, err := fs.Open("OWNERS")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "** @base", string(data))
+	assert.NoError(t, file.Close())
+
+	info, err := fs.Stat("a/OWNERS")
+	assert.NoError(t, err)
+	assert.Equal(t, "OWNERS", info.Name())
+	assert.Equal(t, int64(len("a.go @a_base")), info.Size())
+	assert.False(t, info.IsDir())
+
+	info, err = fs.Stat("a/b")
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	_, err = fs.Stat("x/OWNERS")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = fs.Stat("a/b/c.go/d")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Paths with spaces exist or are missing like any other path.
+	info, err = fs.Stat("my dir/OWNERS")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("** @space_base")), info.Size())
+	_, err = fs.Stat("my dir/other dir/OWNERS")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	names, err := afero.ReadDir(fs, "a")
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+	assert.Equal(t, "OWNERS", names[0].Name())
+	assert.False(t, names[0].IsDir())
+	assert.Equal(t, "b", names[1].Name())
+	assert.True(t, names[1].IsDir())
+
+	names, err = afero.ReadDir(fs, ".")
+	assert.NoError(t, err)
+	assert.Len(t, names, 3)
+
+	assert.Error(t, afero.WriteFile(fs, "OWNERS", []byte("** @write"), 0644))
+
+	matcher := newMatcherWithFs("OWNERS", fs)
+	owners, err := matcher.Match("a/b/c.go")
+	assert.NoError(t, err)
+	assert.Equal(t, []MatchOwner{{Owner: "@base", Type: OwnerUser, Sections: []string{defaultSectionName}}}, owners)
+	owners, err = matcher.Match("x/y.go")
+	assert.NoError(t, err)
+	assert.Equal(t, []MatchOwner{{Owner: "@base", Type: OwnerUser, Sections: []string{defaultSectionName}}}, owners)
+	owners, err = matcher.Match("my dir/other dir/z.go")
+	assert.NoError(t, err)
+	assert.Equal(t, []MatchOwner{{Owner: "@space_base", Type: OwnerUser, Sections: []string{defaultSectionName}}}, owners)
+}
+
+func TestGitFsUnknownRevision(t *testing.T) {
+	dir := t.TempDir()
+	gitCommand(t, dir, "init", "--quiet")
+
+	fs := newGitFs(dir, "does-not-exist")
+	defer fs.Close()
+
+	_, err := fs.Stat("OWNERS")
+	assert.EqualError(t, err, "unknown git revision does-not-exist")
+}
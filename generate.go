@@ -11,7 +11,7 @@ import (
 )
 
 func GenerateCodeOwners(ownersFileName, codeOwnersFilePath string) error {
-	ownersFilePaths, err := findAllOwnersFiles(ownersFileName)
+	ownersFilePaths, err := FindAllOwnersFiles(ownersFileName)
 	if err != nil {
 		return err
 	}
@@ -39,13 +39,13 @@ func GenerateCodeOwners(ownersFileName, codeOwnersFilePath string) error {
 	return writeRequiredRules(f, codeOwnersLines, rules)
 }
 
-func findAllOwnersFiles(ownersFileName string) ([]string, error) {
-	stdout, err := run("git", "ls-files", ownersFileName, fmt.Sprintf("**/%s", ownersFileName))
+func FindAllOwnersFiles(ownersFileName string) ([]string, error) {
+	stdout, err := run("git", "ls-files", "-z", ownersFileName, fmt.Sprintf("**/%s", ownersFileName))
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Fields(stdout)
+	lines := splitPaths(stdout)
 	sort.Strings(lines)
 	return lines, nil
 }
@@ -58,16 +58,18 @@ func getAllRequiredRules(matcher *Matcher, ownersFilePaths []string) ([]*Rule, e
 		if err != nil {
 			return nil, err
 		}
+		// CODEOWNERS has no aliases, so they are expanded.
+		aliases, err := matcher.loadAliases(ownersFileDir)
+		if err != nil {
+			return nil, err
+		}
 
 		for _, section := range ownersFile.Sections {
 			if section.Optional {
 				continue
 			}
 			for _, rule := range section.Rules {
-				owners := rule.Owners
-				if len(owners) == 0 {
-					owners = section.DefaultOwners
-				}
+				owners, _ := expandAliases(section.ruleOwners(rule), aliases)
 				allRequiredRules = append(allRequiredRules, &Rule{
 					Pattern: filepath.Clean(filepath.Join(ownersFileDir, rule.Pattern)),
 					Owners:  owners,
@@ -108,7 +110,8 @@ func writeRequiredRules(w io.Writer, codeOwnersLines []string, rules []*Rule) er
 	writeRules := func() {
 		writeLine(headerLine)
 		for _, rule := range rules {
-			writeLine(fmt.Sprintf("%s %s", rule.Pattern, strings.Join(rule.Owners, " ")))
+			// A pattern without owners leaves matching files unowned.
+			writeLine(strings.Join(append([]string{rule.Pattern}, rule.Owners...), " "))
 		}
 		writeLine(footerLine)
 	}
@@ -0,0 +1,59 @@
+package owners
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRequiredRules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte(`
+		@alias go = @alice @bob
+		**/*.go @go
+		!**/*_generated.go
+		^[docs] @docs
+		*.md
+		`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte("[a] @a\n*.go"), 0644)
+	assert.NoError(t, err)
+
+	rules, err := getAllRequiredRules(newMatcherWithFs("OWNERS", fs), []string{"OWNERS", "a/OWNERS"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = writeRequiredRules(&buf, []string{"# Manual rules.", "* @everyone"}, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, `# Manual rules.
+* @everyone
+
+# Generated by owners tool - do not edit below this line!
+**/*.go @alice @bob
+**/*_generated.go
+a/*.go @a
+# Generated by owners tool - do not edit above this line!
+`, buf.String())
+}
+
+func TestFindAllOwnersFiles(t *testing.T) {
+	dir := t.TempDir()
+	gitCommand(t, dir, "init", "--quiet")
+	writeTestFile(t, dir, "OWNERS", "** @root")
+	writeTestFile(t, dir, "my dir/OWNERS", "** @space")
+	writeTestFile(t, dir, "a/b/OWNERS", "** @b")
+	writeTestFile(t, dir, "a/NOT_OWNERS", "** @a")
+	writeTestFile(t, dir, "untracked/OWNERS", "** @untracked")
+	gitCommand(t, dir, "add", "OWNERS", "my dir", "a")
+
+	chdir(t, dir)
+
+	ownersFiles, err := FindAllOwnersFiles("OWNERS")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OWNERS", "a/b/OWNERS", "my dir/OWNERS"}, ownersFiles)
+}
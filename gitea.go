@@ -0,0 +1,72 @@
+package owners
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Gitea does not document a limit, so comments are kept to the size GitHub accepts.
+const maxGiteaCommentLength = 65536
+
+// GiteaNotifier posts comments on a pull request of Gitea or Forgejo, which
+// share the same REST API.
+type GiteaNotifier struct {
+	// Base URL of the server, e.g. https://gitea.example.com.
+	URL string
+	// Repository in owner/name form.
+	Repository  string
+	PullRequest int
+	Token       string
+}
+
+var _ Notifier = (*GiteaNotifier)(nil)
+
+func (n *GiteaNotifier) FindComments() ([]Comment, error) {
+	var comments []Comment
+	for page := 1; ; page++ {
+		var issueComments []struct {
+			Id   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?page=%d&limit=50", n.Repository, n.PullRequest, page)
+		if err := n.restApi("GET", path, nil, &issueComments); err != nil {
+			return nil, err
+		}
+
+		for _, comment := range issueComments {
+			if strings.HasPrefix(comment.Body, commentHeader) {
+				comments = append(comments, Comment{ID: strconv.FormatInt(comment.Id, 10), Body: comment.Body})
+			}
+		}
+		if len(issueComments) < 50 {
+			return comments, nil
+		}
+	}
+}
+
+func (n *GiteaNotifier) AddComment(body string) error {
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", n.Repository, n.PullRequest)
+	return n.restApi("POST", path, map[string]string{"body": body}, nil)
+}
+
+func (n *GiteaNotifier) UpdateComment(comment Comment, body string) error {
+	path := fmt.Sprintf("/repos/%s/issues/comments/%s", n.Repository, comment.ID)
+	return n.restApi("PATCH", path, map[string]string{"body": body}, nil)
+}
+
+func (n *GiteaNotifier) DeleteComment(comment Comment) error {
+	path := fmt.Sprintf("/repos/%s/issues/comments/%s", n.Repository, comment.ID)
+	return n.restApi("DELETE", path, nil, nil)
+}
+
+func (n *GiteaNotifier) MaxCommentLength() int {
+	return maxGiteaCommentLength
+}
+
+func (n *GiteaNotifier) restApi(method, path string, requestData interface{}, responseData interface{}) error {
+	header := http.Header{}
+	header.Set("Authorization", "token "+n.Token)
+	return restRequest(method, strings.TrimSuffix(n.URL, "/")+"/api/v1"+path, header, requestData, responseData)
+}
@@ -1,14 +1,14 @@
 package owners
 
 import (
-	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/http/httputil"
 	"os"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 const (
@@ -26,18 +26,68 @@ type gitHubEvent struct {
 		NodeID string `json:"node_id"`
 		User   struct {
 			Login string `json:"login"`
-		} `json:"User"`
+		} `json:"user"`
 		Draft bool `json:"draft"`
 	} `json:"pull_request"`
+	// push
+	Before string `json:"before"`
+	After  string `json:"after"`
+	// merge_group
+	MergeGroup struct {
+		BaseSha string `json:"base_sha"`
+		HeadSha string `json:"head_sha"`
+	} `json:"merge_group"`
+	// workflow_dispatch
+	Inputs struct {
+		Base string `json:"base"`
+		Head string `json:"head"`
+	} `json:"inputs"`
 }
 
+// A push that creates or deletes a branch has an all zero before or after sha.
+const zeroSha = "0000000000000000000000000000000000000000"
+
+// OutputTarget is where owners are reported for an event.
+type OutputTarget int
+
+const (
+	// Comment on the pull request.
+	OutputPullRequest OutputTarget = iota
+	// Append to the summary of the workflow job, for events without a pull request.
+	OutputStepSummary
+)
+
 type GitHubActions struct {
+	// Repository in owner/name form.
+	Repository string
+	// Name of the event that triggered the workflow, e.g. pull_request.
+	EventName         string
+	Target            OutputTarget
 	PullRequestNodeID string
 	Draft             bool
-	BaseRef           string
-	HeadRef           string
-	MaxNumOwners      int
-	MaxNumFiles       int
+	// The event has no changes to report, e.g. a push that deletes a branch.
+	Skip    bool
+	BaseRef string
+	HeadRef string
+	// Login of the pull request author, who is never notified.
+	Author string
+	// Owners that are never notified, e.g. bot accounts.
+	ExcludedOwners []string
+	MaxNumOwners   int
+	MaxNumFiles    int
+	// Request reviews from required owners in addition to commenting.
+	EnableReviewRequests bool
+	// Publish owners as a check run in addition to commenting.
+	EnableCheckRun bool
+	// Fail the check run if a changed file has no required owners.
+	RequireOwners bool
+	// Validate owners of changed files against GitHub.
+	EnableOwnerValidation bool
+
+	// Access to the GitHub API, read from the environment if nil.
+	Config *GitHubConfig
+
+	graphql *graphqlClient
 }
 
 func GetGitHubActions() (*GitHubActions, error) {
@@ -45,7 +95,13 @@ func GetGitHubActions() (*GitHubActions, error) {
 	if path == "" {
 		return nil, fmt.Errorf("env var GITHUB_EVENT_PATH not set")
 	}
+	return GetGitHubActionsFromEvent(os.Getenv("GITHUB_EVENT_NAME"), path)
+}
 
+// GetGitHubActionsFromEvent reads the event eventName from the JSON file at path,
+// e.g. a local copy of an event for a dry run. Inputs are still read from
+// the environment. eventName defaults to pull_request.
+func GetGitHubActionsFromEvent(eventName, path string) (*GitHubActions, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read GitHub event json %s: %s", path, err)
@@ -56,26 +112,106 @@ func GetGitHubActions() (*GitHubActions, error) {
 		return nil, fmt.Errorf("unable to decode GitHub event: %s\n%s", err, string(data))
 	}
 
+	g := &GitHubActions{
+		Repository: os.Getenv("GITHUB_REPOSITORY"),
+		EventName:  eventName,
+	}
+	config := GitHubConfigFromEnv()
+	g.Config = &config
+	if g.EventName == "" {
+		g.EventName = "pull_request"
+	}
+
+	switch g.EventName {
+	case "pull_request", "pull_request_target", "pull_request_review", "pull_request_review_comment":
+		g.Target = OutputPullRequest
+		g.PullRequestNodeID = event.PullRequest.NodeID
+		g.Draft = event.PullRequest.Draft
+		g.BaseRef = event.PullRequest.Base.Sha
+		g.HeadRef = event.PullRequest.Head.Sha
+		g.Author = event.PullRequest.User.Login
+	case "push":
+		// A push that creates or deletes a branch has no base to diff against.
+		g.Skip = event.Before == zeroSha || event.After == zeroSha
+		g.Target = OutputStepSummary
+		g.BaseRef = event.Before
+		g.HeadRef = event.After
+	case "merge_group":
+		g.Target = OutputStepSummary
+		g.BaseRef = event.MergeGroup.BaseSha
+		g.HeadRef = event.MergeGroup.HeadSha
+	case "workflow_dispatch":
+		g.Target = OutputStepSummary
+		g.BaseRef = event.Inputs.Base
+		g.HeadRef = event.Inputs.Head
+		if g.HeadRef == "" {
+			g.HeadRef = os.Getenv("GITHUB_SHA")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported GitHub event %s", g.EventName)
+	}
+	if g.BaseRef == "" || g.HeadRef == "" {
+		return nil, fmt.Errorf("unable to find base and head of %s event", g.EventName)
+	}
+
 	maxNumOwners, _ := strconv.Atoi(os.Getenv("INPUT_MAX_NUM_OWNERS"))
 	maxNumFiles, _ := strconv.Atoi(os.Getenv("INPUT_MAX_NUM_FILES"))
+	requestReviews, _ := strconv.ParseBool(os.Getenv("INPUT_REQUEST_REVIEWS"))
+	checkRun, _ := strconv.ParseBool(os.Getenv("INPUT_CHECK_RUN"))
+	requireOwners, _ := strconv.ParseBool(os.Getenv("INPUT_REQUIRE_OWNERS"))
+	validateOwners, _ := strconv.ParseBool(os.Getenv("INPUT_VALIDATE_OWNERS"))
+	excludedOwners := strings.FieldsFunc(os.Getenv("INPUT_EXCLUDE"), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	g.ExcludedOwners = excludedOwners
+	g.MaxNumOwners = maxNumOwners
+	g.MaxNumFiles = maxNumFiles
+	g.EnableReviewRequests = requestReviews
+	g.EnableCheckRun = checkRun
+	g.RequireOwners = requireOwners
+	g.EnableOwnerValidation = validateOwners
+
+	return g, nil
+}
+
+func (g *GitHubActions) config() *GitHubConfig {
+	if g.Config == nil {
+		config := GitHubConfigFromEnv()
+		g.Config = &config
+	}
+	return g.Config
+}
+
+// api returns the client for the GitHub GraphQL API.
+func (g *GitHubActions) api() *graphqlClient {
+	if g.graphql == nil {
+		g.graphql = newGraphqlClient(g.config().GraphQLURL, g.config().Token)
+	}
+	return g.graphql
+}
 
-	return &GitHubActions{
-		PullRequestNodeID: event.PullRequest.NodeID,
-		Draft:             event.PullRequest.Draft,
-		BaseRef:           event.PullRequest.Base.Sha,
-		HeadRef:           event.PullRequest.Head.Sha,
-		MaxNumOwners:      maxNumOwners,
-		MaxNumFiles:       maxNumFiles,
-	}, nil
+// Differ returns the files changed between the base and head of the event.
+func (g *GitHubActions) Differ() Differ {
+	return NewGitDiffer(g.BaseRef, g.HeadRef)
 }
 
 func (g *GitHubActions) Prepare() error {
-	commitCount, err := getCommitCount(g.PullRequestNodeID)
+	var commitCount int
+	var err error
+	if g.Target == OutputPullRequest {
+		commitCount, err = g.api().getCommitCount(g.PullRequestNodeID)
+	} else {
+		commitCount, err = g.getCompareCommitCount()
+	}
 	if err != nil {
 		return err
 	}
 
-	_, err = run("git", "-c", "protocol.version=2", "fetch", "--deepen", strconv.Itoa(commitCount+1))
+	// Fetch the base and head explicitly, since the checkout may contain
+	// neither: pull_request_target checks out the base branch, and a force
+	// push replaces the commit before it.
+	_, err = run("git", "-c", "protocol.version=2", "fetch", "--no-tags", "--depth", strconv.Itoa(commitCount+1), "origin", g.BaseRef, g.HeadRef)
 	if err != nil {
 		return err
 	}
@@ -83,63 +219,148 @@ func (g *GitHubActions) Prepare() error {
 	return nil
 }
 
+// ExcludeOwners drops the pull request author and excluded owners from results.
+func (g *GitHubActions) ExcludeOwners(results FindResults) FindResults {
+	return ExcludeAuthor(results, g.Author, g.ExcludedOwners)
+}
+
+// WriteComment reports results on the pull request, or in the job summary
+// for events without a pull request.
 func (g *GitHubActions) WriteComment(results FindResults) error {
-	comment := g.writeComment(results)
+	if g.Target == OutputStepSummary {
+		for _, comment := range g.writeComments(results) {
+			if err := writeStepSummary(comment); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 
-	commentId, err := findExistingCommentId(g.PullRequestNodeID)
-	if err != nil {
-		return err
+	return WriteComments(gitHubNotifier{client: g.api(), prNodeID: g.PullRequestNodeID}, results, g.commentOptions())
+}
+
+func (g *GitHubActions) commentOptions() CommentOptions {
+	return CommentOptions{
+		BaseRef:      g.BaseRef,
+		HeadRef:      g.HeadRef,
+		MaxNumOwners: g.MaxNumOwners,
+		MaxNumFiles:  g.MaxNumFiles,
 	}
+}
 
-	if commentId == "" {
-		// No comment exists and we don't need to notify any owners, so skip commenting.
-		if len(results.Owners) == 0 {
-			return nil
-		}
-		return addComment(g.PullRequestNodeID, comment)
+// GitHub rejects comment bodies longer than 65536 characters.
+const maxGitHubCommentLength = 65536
+
+// RenderComments returns the comment bodies that WriteComment would post for
+// results, without calling the GitHub API.
+func (g *GitHubActions) RenderComments(results FindResults) []string {
+	return g.writeComments(results)
+}
+
+// writeComments renders results into one or more comment bodies that each
+// fit in a GitHub comment.
+func (g *GitHubActions) writeComments(results FindResults) []string {
+	return commentWriter{
+		baseRef:      g.BaseRef,
+		headRef:      g.HeadRef,
+		maxNumOwners: g.MaxNumOwners,
+		maxNumFiles:  g.MaxNumFiles,
+		maxLength:    maxGitHubCommentLength,
+		header:       commentHeader,
+	}.write(results)
+}
+
+// gitHubNotifier posts comments on a pull request with the GraphQL API.
+type gitHubNotifier struct {
+	client   *graphqlClient
+	prNodeID string
+}
+
+func (n gitHubNotifier) FindComments() ([]Comment, error) {
+	ids, err := n.client.findExistingCommentIds(n.prNodeID)
+	if err != nil {
+		return nil, err
+	}
+	var comments []Comment
+	for _, id := range ids {
+		comments = append(comments, Comment{ID: id})
 	}
+	return comments, nil
+}
 
-	return updateComment(commentId, comment)
+func (n gitHubNotifier) AddComment(body string) error {
+	return n.client.addComment(n.prNodeID, body)
 }
 
-func (g *GitHubActions) writeComment(results FindResults) string {
-	w := &strings.Builder{}
+func (n gitHubNotifier) UpdateComment(comment Comment, body string) error {
+	return n.client.updateComment(comment.ID, body)
+}
 
-	writeLinef := func(format string, args ...interface{}) {
-		w.WriteString(fmt.Sprintf(format, args...))
-		w.WriteRune('\n')
+func (n gitHubNotifier) DeleteComment(comment Comment) error {
+	return n.client.deleteComment(comment.ID)
+}
+
+func (n gitHubNotifier) MaxCommentLength() int {
+	return maxGitHubCommentLength
+}
+
+// RequestReviews requests reviews from the required owners in results.
+// Owners that are not GitHub users or teams, or that do not exist, are
+// skipped. Like the comment, no owners are notified if there are more than
+// MaxNumOwners.
+func (g *GitHubActions) RequestReviews(results FindResults) error {
+	if g.MaxNumOwners > 0 && len(results.Owners) > g.MaxNumOwners {
+		fmt.Printf("::notice::Not requesting reviews because the number of owners (%d) exceeds the threshold (%d)\n", len(results.Owners), g.MaxNumOwners)
+		return nil
 	}
 
-	writeLinef(commentHeader)
-	writeLinef("[Owners](https://github.com/martin-vanta/owners): Notifying file owners for diff %s...%s.\n\n", g.BaseRef, g.HeadRef)
-	if len(results.Owners) == 0 {
-		writeLinef("No notifications.")
-	} else {
-		writeLinef("| Owner | Required | File(s) |")
-		writeLinef("|-|-|-|")
-		for _, owner := range results.Owners {
-			var required string
-			if !owner.Optional {
-				required = "✅"
-			}
+	var userIds, teamIds []string
+	for _, owner := range results.Owners {
+		if owner.Optional {
+			continue
+		}
 
-			files := owner.FilePaths
-			if len(files) > g.MaxNumFiles {
-				files := make([]string, g.MaxNumFiles+1)
-				copy(files, owner.FilePaths[:g.MaxNumFiles])
-				files[g.MaxNumFiles] = "..."
+		var id string
+		var err error
+		typ := ownerType(owner.Owner)
+		handle := strings.TrimPrefix(owner.Owner, "@")
+		switch typ {
+		case OwnerUser:
+			id, err = g.api().getUserId(handle)
+		case OwnerTeam:
+			// GitHub teams are always @org/team, nested names are GitLab groups.
+			org, slug, _ := strings.Cut(handle, "/")
+			if strings.Contains(slug, "/") {
+				continue
 			}
+			id, err = g.api().getTeamId(org, slug)
+		default:
+			continue
+		}
+		if errors.Is(err, errGraphqlNotFound) {
+			fmt.Printf("::warning::Not requesting review from %s: %v\n", owner.Owner, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
 
-			writeLinef("| %s | %s | %s |", owner.Owner, required, strings.Join(files, "<br>"))
+		if typ == OwnerTeam {
+			teamIds = append(teamIds, id)
+		} else {
+			userIds = append(userIds, id)
 		}
 	}
 
-	return w.String()
+	if len(userIds) == 0 && len(teamIds) == 0 {
+		return nil
+	}
+
+	return g.api().requestReviews(g.PullRequestNodeID, userIds, teamIds)
 }
 
-func updateComment(id, body string) error {
-	// fmt.Fprintf(verbose, "updating existing comment: %s\n", id)
-	return graphql(`
+func (c *graphqlClient) updateComment(id, body string) error {
+	return c.query(`
 		mutation UpdateComment ($id: ID!, $body: String!) {
 			updateIssueComment(input: {
 				id: $id
@@ -156,9 +377,24 @@ func updateComment(id, body string) error {
 	)
 }
 
-func addComment(subjectId, body string) error {
-	// fmt.Fprintf(verbose, "adding comment to pr %s\n", subjectId)
-	return graphql(`
+func (c *graphqlClient) deleteComment(id string) error {
+	return c.query(`
+		mutation DeleteComment ($id: ID!) {
+			deleteIssueComment(input: {
+				id: $id
+			}) {
+				clientMutationId
+			}
+		}`,
+		map[string]interface{}{
+			"id": id,
+		},
+		nil,
+	)
+}
+
+func (c *graphqlClient) addComment(subjectId, body string) error {
+	return c.query(`
 		mutation AddComment ($subjectId: ID!, $body: String!) {
 			addComment(input: {
 				subjectId: $subjectId
@@ -175,7 +411,85 @@ func addComment(subjectId, body string) error {
 	)
 }
 
-func getCommitCount(prNodeID string) (int, error) {
+func (c *graphqlClient) requestReviews(prNodeID string, userIds, teamIds []string) error {
+	return c.query(`
+		mutation RequestReviews ($pullRequestId: ID!, $userIds: [ID!], $teamIds: [ID!]) {
+			requestReviews(input: {
+				pullRequestId: $pullRequestId
+				userIds: $userIds
+				teamIds: $teamIds
+				union: true
+			}) {
+				clientMutationId
+			}
+		}`,
+		map[string]interface{}{
+			"pullRequestId": prNodeID,
+			"userIds":       userIds,
+			"teamIds":       teamIds,
+		},
+		nil,
+	)
+}
+
+func (c *graphqlClient) getUserId(login string) (string, error) {
+	data := struct {
+		User *struct {
+			Id string `json:"id"`
+		} `json:"user"`
+	}{}
+	err := c.query(`
+		query UserId ($login: String!) {
+			user(login: $login) {
+				id
+			}
+		}`,
+		map[string]interface{}{
+			"login": login,
+		},
+		&data,
+	)
+	if err != nil && !errors.Is(err, errGraphqlNotFound) {
+		return "", err
+	}
+	if data.User == nil {
+		return "", fmt.Errorf("GitHub user %s %w", login, errGraphqlNotFound)
+	}
+	return data.User.Id, nil
+}
+
+func (c *graphqlClient) getTeamId(org, slug string) (string, error) {
+	data := struct {
+		Organization *struct {
+			Team *struct {
+				Id string `json:"id"`
+			} `json:"team"`
+		} `json:"organization"`
+	}{}
+	err := c.query(`
+		query TeamId ($org: String!, $slug: String!) {
+			organization(login: $org) {
+				team(slug: $slug) {
+					id
+				}
+			}
+		}`,
+		map[string]interface{}{
+			"org":  org,
+			"slug": slug,
+		},
+		&data,
+	)
+	if err != nil && !errors.Is(err, errGraphqlNotFound) {
+		return "", err
+	}
+	if data.Organization == nil || data.Organization.Team == nil {
+		return "", fmt.Errorf("GitHub team %s/%s %w", org, slug, errGraphqlNotFound)
+	}
+	return data.Organization.Team.Id, nil
+}
+
+func (c *graphqlClient) getCommitCount(prNodeID string) (int, error) {
 	data := struct {
 		Node struct {
 			Commits struct {
@@ -183,7 +497,7 @@ func getCommitCount(prNodeID string) (int, error) {
 			} `json:"commits"`
 		} `json:"node"`
 	}{}
-	err := graphql(`
+	err := c.query(`
 		query CommitCount ($nodeId: ID!) {
 			node(id: $nodeId) {
 				... on PullRequest {
@@ -202,32 +516,48 @@ func getCommitCount(prNodeID string) (int, error) {
 	return data.Node.Commits.TotalCount, err
 }
 
-func findExistingCommentId(prNodeID string) (string, error) {
+// getCompareCommitCount returns the number of commits in head that are not in base.
+// getCompareCommitCount returns the number of commits from the base or the
+// head to their merge base, whichever is larger. The base is not an ancestor
+// of the head after a force push.
+func (g *GitHubActions) getCompareCommitCount() (int, error) {
 	data := struct {
+		AheadBy  int `json:"ahead_by"`
+		BehindBy int `json:"behind_by"`
+	}{}
+	err := g.restApi("GET", fmt.Sprintf("/repos/%s/compare/%s...%s", g.Repository, g.BaseRef, g.HeadRef), nil, &data)
+	if data.BehindBy > data.AheadBy {
+		return data.BehindBy, err
+	}
+	return data.AheadBy, err
+}
+
+func (c *graphqlClient) findExistingCommentIds(prNodeID string) ([]string, error) {
+	type commentsPage struct {
 		Node struct {
 			Comments struct {
 				Nodes []struct {
-					Id     string `json:"id"`
-					Author struct {
-						Login string `json:"login"`
-					} `json:"author"`
+					Id   string `json:"id"`
 					Body string `json:"body"`
 				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
 			} `json:"comments"`
 		} `json:"node"`
-	}{}
-	err := graphql(`
-		query GetPullRequestComments ($nodeId: ID!) {
+	}
+	var pages []*commentsPage
+	err := c.queryPages(`
+		query GetPullRequestComments ($nodeId: ID!, $cursor: String) {
 			node(id: $nodeId) {
 				... on PullRequest {
-					comments(first: 100) {
+					comments(first: 100, after: $cursor) {
 						nodes {
 							id
-							author {
-								login
-							}
 							body
 						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
 					}
 				}
 			}
@@ -235,80 +565,36 @@ func findExistingCommentId(prNodeID string) (string, error) {
 		map[string]interface{}{
 			"nodeId": prNodeID,
 		},
-		&data,
+		func() (interface{}, func() pageInfo) {
+			page := &commentsPage{}
+			pages = append(pages, page)
+			return page, func() pageInfo { return page.Node.Comments.PageInfo }
+		},
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	for _, comment := range data.Node.Comments.Nodes {
-		if strings.HasPrefix(comment.Body, commentHeader) {
-			return comment.Id, nil
+	var ids []string
+	for _, page := range pages {
+		for _, comment := range page.Node.Comments.Nodes {
+			if strings.HasPrefix(comment.Body, commentHeader) {
+				ids = append(ids, comment.Id)
+			}
 		}
 	}
 
-	return "", nil
+	return ids, nil
 }
 
-func graphql(query string, variables map[string]interface{}, responseData interface{}) error {
-	reqbody, err := json.Marshal(map[string]interface{}{
-		"query":     query,
-		"variables": variables,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to marshal query %s and variables %s: %w", query, variables, err)
-	}
-
-	req, err := http.NewRequest(http.MethodPost, os.Getenv("GITHUB_GRAPHQL_URL"), bytes.NewBuffer(reqbody))
+func (g *GitHubActions) restApi(method, path string, requestData interface{}, responseData interface{}) error {
+	token, err := g.config().Token.Token()
 	if err != nil {
 		return err
 	}
+	header := http.Header{}
+	header.Set("Authorization", "bearer "+token)
+	header.Set("Accept", "application/vnd.github+json")
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		return fmt.Errorf("GITHUB_TOKEN is not set")
-	}
-	req.Header.Set("Authorization", "bearer "+token)
-
-	reqdump, err := httputil.DumpRequestOut(req, true)
-	if err != nil {
-		return fmt.Errorf("error dumping request: %w", err)
-	}
-
-	cl := &http.Client{}
-	resp, err := cl.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	respdump, err := httputil.DumpResponse(resp, true)
-	if err != nil {
-		return fmt.Errorf("error dumping response: %w", err)
-	}
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("non-200 response:\n%s\n\nrequest:\n%s", string(respdump), string(reqdump))
-	}
-
-	response := struct {
-		Data   interface{}
-		Errors []struct {
-			Type    string   `json:"type"`
-			Path    []string `json:"path"`
-			Message string   `json:"message"`
-		} `json:"errors"`
-	}{
-		Data: responseData,
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return fmt.Errorf("error decoding json response:\n%s\n%w", respdump, err)
-	}
-
-	if len(response.Errors) > 0 {
-		return fmt.Errorf("graphql error: %s\nrequest:\n%s", response.Errors[0].Message, reqdump)
-	}
-
-	return nil
+	return restRequest(method, strings.TrimSuffix(g.config().APIURL, "/")+path, header, requestData, responseData)
 }
@@ -0,0 +1,527 @@
+package owners
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTestEvent(t *testing.T, event string) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(path, []byte(event), 0644))
+	t.Setenv("GITHUB_EVENT_PATH", path)
+}
+
+func TestGetGitHubActions(t *testing.T) {
+	setTestEvent(t, `{
+		"pull_request": {
+			"base": {"sha": "base"},
+			"head": {"sha": "head"},
+			"node_id": "PR_1",
+			"user": {"login": "alice"},
+			"draft": true
+		}
+	}`)
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	t.Setenv("INPUT_EXCLUDE", "@dependabot, renovate")
+	t.Setenv("INPUT_MAX_NUM_OWNERS", "10")
+	t.Setenv("INPUT_MAX_NUM_FILES", "20")
+	t.Setenv("GITHUB_API_URL", "https://github.example.com/api/v3")
+	t.Setenv("GITHUB_GRAPHQL_URL", "https://github.example.com/api/graphql")
+	t.Setenv("GITHUB_TOKEN", "token")
+
+	actions, err := GetGitHubActions()
+	assert.NoError(t, err)
+	assert.Equal(t, &GitHubActions{
+		Repository:        "org/repo",
+		EventName:         "pull_request",
+		Target:            OutputPullRequest,
+		PullRequestNodeID: "PR_1",
+		Draft:             true,
+		BaseRef:           "base",
+		HeadRef:           "head",
+		Author:            "alice",
+		ExcludedOwners:    []string{"@dependabot", "renovate"},
+		MaxNumOwners:      10,
+		MaxNumFiles:       20,
+		Config: &GitHubConfig{
+			APIURL:     "https://github.example.com/api/v3",
+			GraphQLURL: "https://github.example.com/api/graphql",
+			Token:      StaticToken("token"),
+		},
+	}, actions)
+
+	results := actions.ExcludeOwners(FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+		{Owner: "@renovate", FilePaths: []string{"go.mod"}},
+	}})
+	assert.Equal(t, FindResults{Owners: []FindResult{
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+	}}, results)
+}
+
+func TestGetGitHubActionsEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		base    string
+		head    string
+		target  OutputTarget
+		skip    bool
+		wantErr string
+	}{
+		{
+			name:   "pull_request_target",
+			event:  `{"pull_request": {"base": {"sha": "base"}, "head": {"sha": "head"}}}`,
+			base:   "base",
+			head:   "head",
+			target: OutputPullRequest,
+		},
+		{
+			name:   "pull_request_review",
+			event:  `{"pull_request": {"base": {"sha": "base"}, "head": {"sha": "head"}}}`,
+			base:   "base",
+			head:   "head",
+			target: OutputPullRequest,
+		},
+		{
+			name:   "pull_request_review_comment",
+			event:  `{"pull_request": {"base": {"sha": "base"}, "head": {"sha": "head"}}}`,
+			base:   "base",
+			head:   "head",
+			target: OutputPullRequest,
+		},
+		{
+			name:   "push",
+			event:  `{"before": "before", "after": "after"}`,
+			base:   "before",
+			head:   "after",
+			target: OutputStepSummary,
+		},
+		{
+			name:   "merge_group",
+			event:  `{"merge_group": {"base_sha": "base", "head_sha": "head"}}`,
+			base:   "base",
+			head:   "head",
+			target: OutputStepSummary,
+		},
+		{
+			name:   "workflow_dispatch",
+			event:  `{"inputs": {"base": "base"}}`,
+			base:   "base",
+			head:   "sha",
+			target: OutputStepSummary,
+		},
+		{
+			name:   "push",
+			event:  `{"before": "0000000000000000000000000000000000000000", "after": "after"}`,
+			base:   "0000000000000000000000000000000000000000",
+			head:   "after",
+			target: OutputStepSummary,
+			skip:   true,
+		},
+		{
+			name:    "workflow_dispatch",
+			event:   `{"inputs": {}}`,
+			wantErr: "unable to find base and head of workflow_dispatch event",
+		},
+		{
+			name:    "issue_comment",
+			event:   `{}`,
+			wantErr: "unsupported GitHub event issue_comment",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			setTestEvent(t, test.event)
+			t.Setenv("GITHUB_EVENT_NAME", test.name)
+			t.Setenv("GITHUB_SHA", "sha")
+
+			actions, err := GetGitHubActions()
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.name, actions.EventName)
+			assert.Equal(t, test.base, actions.BaseRef)
+			assert.Equal(t, test.head, actions.HeadRef)
+			assert.Equal(t, test.target, actions.Target)
+			assert.Equal(t, test.skip, actions.Skip)
+		})
+	}
+}
+
+func TestGetGitHubActionsFromEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"before": "before", "after": "after"}`), 0644))
+	t.Setenv("GITHUB_EVENT_PATH", "")
+	t.Setenv("GITHUB_EVENT_NAME", "pull_request")
+
+	actions, err := GetGitHubActionsFromEvent("push", path)
+	require.NoError(t, err)
+	assert.Equal(t, "push", actions.EventName)
+	assert.Equal(t, "before", actions.BaseRef)
+	assert.Equal(t, "after", actions.HeadRef)
+	assert.Equal(t, actions.writeComments(FindResults{}), actions.RenderComments(FindResults{}))
+}
+
+func TestWriteCommentStepSummary(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	actions := &GitHubActions{Target: OutputStepSummary, BaseRef: "base", HeadRef: "head"}
+	err := actions.WriteComment(FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+	}})
+	require.NoError(t, err)
+
+	summary, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(summary), "diff base...head")
+	assert.Contains(t, string(summary), "| @alice |")
+}
+
+func TestWriteCommentMaxNumOwners(t *testing.T) {
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@a", Sections: []string{"backend"}, FilePaths: []string{"a/a.go", "b/b.go"}},
+		{Owner: "@b", Sections: []string{"backend", "docs"}, FilePaths: []string{"a/b.go", "readme.md"}},
+		{Owner: "@c", Optional: true, Sections: []string{"docs"}, FilePaths: []string{"a/a.go"}},
+	}}
+
+	actions := &GitHubActions{BaseRef: "base", HeadRef: "head", MaxNumOwners: 2}
+	assert.Equal(t, []string{commentHeader + `
+[Owners](https://github.com/martin-vanta/owners): Notifying file owners for diff base...head.
+
+
+Not notifying owners because the number of owners (3) exceeds the threshold (2).
+
+<details><summary>Summary of 3 owners</summary>
+
+| Section | Required owners | Optional owners |
+|-|-|-|
+| backend | 2 | 0 |
+| docs | 1 | 1 |
+
+| Directory | Files |
+|-|-|
+| a | 2 |
+| . | 1 |
+| b | 1 |
+
+</details>
+`}, actions.writeComments(results))
+
+	actions.MaxNumOwners = 3
+	assert.Contains(t, actions.writeComments(results)[0], "| @c |  | a/a.go |")
+}
+
+func TestFormatCommentFiles(t *testing.T) {
+	files := []string{"a.go", "b.go", "c.go", "d.go", "e.go"}
+
+	tests := []struct {
+		files       []string
+		maxNumFiles int
+		expected    string
+	}{
+		{files: files[:1], maxNumFiles: 5, expected: "a.go"},
+		{files: files[:3], maxNumFiles: 5, expected: "a.go<br>b.go<br>c.go"},
+		{files: files, maxNumFiles: 5, expected: "a.go<br>b.go<br>c.go<details><summary>+2 more</summary>d.go<br>e.go</details>"},
+		{files: files, maxNumFiles: 4, expected: "a.go<br>b.go<br>c.go<details><summary>+2 more</summary>d.go<br>...and 1 more not shown</details>"},
+		{files: files, maxNumFiles: 2, expected: "a.go<br>b.go<details><summary>+3 more</summary>...and 3 more not shown</details>"},
+		{files: files[:3], maxNumFiles: 2, expected: "a.go<br>b.go<details><summary>+1 more</summary>...and 1 more not shown</details>"},
+	}
+	for _, test := range tests {
+		got := formatCommentFiles(test.files, test.maxNumFiles)
+		assert.Equal(t, test.expected, got, "files: %v, max: %d", test.files, test.maxNumFiles)
+	}
+}
+
+func TestWriteCommentsMaxNumFiles(t *testing.T) {
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@a", FilePaths: []string{"a.go", "b.go", "c.go", "d.go", "e.go"}},
+	}}
+
+	// Zero disables truncation.
+	actions := &GitHubActions{BaseRef: "base", HeadRef: "head"}
+	comments := actions.writeComments(results)
+	assert.Len(t, comments, 1)
+	assert.Contains(t, comments[0], "| @a | ✅ | a.go<br>b.go<br>c.go<details><summary>+2 more</summary>d.go<br>e.go</details> |")
+
+	actions.MaxNumFiles = 1
+	comments = actions.writeComments(results)
+	assert.Len(t, comments, 1)
+	assert.Contains(t, comments[0], "| @a | ✅ | a.go<details><summary>+4 more</summary>...and 4 more not shown</details> |")
+}
+
+func TestWriteCommentsSplit(t *testing.T) {
+	var results FindResults
+	for i := 0; i < 100; i++ {
+		var filePaths []string
+		for j := 0; j < 100; j++ {
+			filePaths = append(filePaths, fmt.Sprintf("dir%d/file%d.go", i, j))
+		}
+		results.Owners = append(results.Owners, FindResult{Owner: fmt.Sprintf("@owner%d", i), FilePaths: filePaths})
+	}
+	// One owner with more files than fit in a single comment.
+	var filePaths []string
+	for j := 0; j < 10000; j++ {
+		filePaths = append(filePaths, fmt.Sprintf("huge/file%d.go", j))
+	}
+	results.Owners = append(results.Owners, FindResult{Owner: "@huge", FilePaths: filePaths})
+
+	actions := &GitHubActions{BaseRef: "base", HeadRef: "head"}
+	comments := actions.writeComments(results)
+	assert.Greater(t, len(comments), 1)
+
+	var joined strings.Builder
+	for i, comment := range comments {
+		assert.LessOrEqual(t, len(comment), maxGitHubCommentLength)
+		assert.True(t, strings.HasPrefix(comment, commentHeader))
+		assert.Contains(t, comment, fmt.Sprintf("(part %d of %d)", i+1, len(comments)))
+		joined.WriteString(comment)
+	}
+	for _, owner := range results.Owners {
+		assert.Contains(t, joined.String(), "| "+owner.Owner+" |")
+	}
+	assert.Contains(t, joined.String(), "more not shown")
+}
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// graphqlResponse is returned by handlers of newTestGraphQLServer to answer
+// with errors in addition to data.
+type graphqlResponse struct {
+	Data   interface{}    `json:"data"`
+	Errors []graphqlError `json:"errors,omitempty"`
+}
+
+// newTestGraphQLServer starts a stand-in for the GitHub GraphQL API that
+// answers requests with the data returned by handler.
+func newTestGraphQLServer(t *testing.T, handler func(req graphqlRequest) interface{}) *[]graphqlRequest {
+	var requests []graphqlRequest
+	url, _ := newTestServer(t, "Authorization", "bearer test-token", func(r testRequest) testResponse {
+		req := graphqlRequest{}
+		req.Query, _ = r.body["query"].(string)
+		req.Variables, _ = r.body["variables"].(map[string]interface{})
+		requests = append(requests, req)
+
+		response, ok := handler(req).(graphqlResponse)
+		if !ok {
+			response = graphqlResponse{Data: handler(req)}
+		}
+		body, err := json.Marshal(response)
+		if err != nil {
+			t.Errorf("invalid response: %s", err)
+			return testResponse{status: http.StatusInternalServerError}
+		}
+		return testResponse{body: string(body)}
+	})
+
+	t.Setenv("GITHUB_GRAPHQL_URL", url)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	return &requests
+}
+
+func TestRequestReviews(t *testing.T) {
+	requests := newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		switch {
+		case strings.Contains(req.Query, "user(login"):
+			return map[string]interface{}{"user": map[string]interface{}{"id": "U_" + req.Variables["login"].(string)}}
+		case strings.Contains(req.Query, "organization(login"):
+			return map[string]interface{}{"organization": map[string]interface{}{"team": map[string]interface{}{"id": "T_" + req.Variables["slug"].(string)}}}
+		default:
+			return map[string]interface{}{}
+		}
+	})
+
+	actions := &GitHubActions{PullRequestNodeID: "PR_1"}
+	err := actions.RequestReviews(FindResults{Owners: []FindResult{
+		{Owner: "@alice"},
+		{Owner: "@bob", Optional: true},
+		{Owner: "@org/team"},
+		{Owner: "jane@example.com"},
+		{Owner: "@@maintainer"},
+		{Owner: "@group/subgroup/team"},
+	}})
+	assert.NoError(t, err)
+
+	assert.Len(t, *requests, 3)
+	mutation := (*requests)[2]
+	assert.Contains(t, mutation.Query, "requestReviews")
+	assert.Equal(t, map[string]interface{}{
+		"pullRequestId": "PR_1",
+		"userIds":       []interface{}{"U_alice"},
+		"teamIds":       []interface{}{"T_team"},
+	}, mutation.Variables)
+}
+
+func TestRequestReviewsMaxNumOwners(t *testing.T) {
+	requests := newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		return map[string]interface{}{"user": map[string]interface{}{"id": "U_" + req.Variables["login"].(string)}}
+	})
+
+	actions := &GitHubActions{PullRequestNodeID: "PR_1", MaxNumOwners: 1}
+	err := actions.RequestReviews(FindResults{Owners: []FindResult{
+		{Owner: "@alice"},
+		{Owner: "@bob"},
+	}})
+	assert.NoError(t, err)
+	assert.Empty(t, *requests)
+}
+
+func TestRequestReviewsUnknownOwner(t *testing.T) {
+	requests := newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		switch {
+		case req.Variables["login"] == "typo":
+			return graphqlResponse{Data: map[string]interface{}{"user": nil}, Errors: []graphqlError{{
+				Type:    "NOT_FOUND",
+				Message: "Could not resolve to a User with the login of 'typo'.",
+			}}}
+		case strings.Contains(req.Query, "user(login"):
+			return map[string]interface{}{"user": map[string]interface{}{"id": "U_" + req.Variables["login"].(string)}}
+		case strings.Contains(req.Query, "organization(login"):
+			return map[string]interface{}{"organization": map[string]interface{}{"team": nil}}
+		default:
+			return map[string]interface{}{}
+		}
+	})
+
+	// Owners that do not exist are skipped.
+	actions := &GitHubActions{PullRequestNodeID: "PR_1"}
+	err := actions.RequestReviews(FindResults{Owners: []FindResult{
+		{Owner: "@typo"},
+		{Owner: "@org/plaform"},
+		{Owner: "@alice"},
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 4)
+	assert.Equal(t, map[string]interface{}{
+		"pullRequestId": "PR_1",
+		"userIds":       []interface{}{"U_alice"},
+		"teamIds":       nil,
+	}, (*requests)[3].Variables)
+
+	// Nothing is requested without required owners.
+	err = actions.RequestReviews(FindResults{Owners: []FindResult{{Owner: "@bob", Optional: true}}})
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 4)
+}
+
+func TestGetApprovers(t *testing.T) {
+	newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		assert.Equal(t, "PR_1", req.Variables["nodeId"])
+		return map[string]interface{}{"node": map[string]interface{}{
+			"latestOpinionatedReviews": map[string]interface{}{"nodes": []interface{}{
+				map[string]interface{}{"state": "APPROVED", "author": map[string]interface{}{"login": "alice"}},
+				map[string]interface{}{"state": "CHANGES_REQUESTED", "author": map[string]interface{}{"login": "bob"}},
+			}},
+		}}
+	})
+
+	actions := &GitHubActions{PullRequestNodeID: "PR_1"}
+	approvers, err := actions.GetApprovers()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"@alice"}, approvers)
+}
+
+func TestWriteApprovalStatus(t *testing.T) {
+	url, requests := newTestServer(t, "Authorization", "bearer test-token", func(req testRequest) testResponse {
+		return testResponse{status: http.StatusCreated}
+	})
+	t.Setenv("GITHUB_API_URL", url)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	actions := &GitHubActions{Repository: "org/repo", HeadRef: "head"}
+	approvals := Approvals{Sections: []SectionApproval{{Name: "backend", Approvals: 1, FilePaths: []string{"a.go"}}}}
+	err := actions.WriteApprovalStatus(approvals)
+	assert.NoError(t, err)
+	assert.Equal(t, []testRequest{{
+		method: "POST",
+		uri:    "/repos/org/repo/statuses/head",
+		body: map[string]interface{}{
+			"state":       "pending",
+			"description": "0 of 1 required sections approved",
+			"context":     "owners/approvals",
+		},
+	}}, *requests)
+
+	summary, err := os.ReadFile(summaryPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(summary), "| backend | ✅ | 1 |  | 1 | ⏳ |")
+}
+
+func TestWriteCheckRun(t *testing.T) {
+	url, requests := newTestServer(t, "Authorization", "bearer test-token", func(req testRequest) testResponse {
+		return testResponse{status: http.StatusCreated, body: `{"id": 42}`}
+	})
+	t.Setenv("GITHUB_API_URL", url)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	var filePaths []string
+	for i := 0; i < 60; i++ {
+		filePaths = append(filePaths, fmt.Sprintf("file%02d.go", i))
+	}
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: filePaths[1:]},
+		{Owner: "@bob", Optional: true, FilePaths: filePaths[:1]},
+	}}
+
+	actions := &GitHubActions{Repository: "org/repo", HeadRef: "head", RequireOwners: true}
+	err := actions.WriteCheckRun(filePaths, results)
+	require.NoError(t, err)
+	require.Len(t, *requests, 2)
+
+	assert.Equal(t, "POST", (*requests)[0].method)
+	assert.Equal(t, "/repos/org/repo/check-runs", (*requests)[0].uri)
+	assert.Equal(t, "owners", (*requests)[0].body["name"])
+	assert.Equal(t, "head", (*requests)[0].body["head_sha"])
+	assert.Equal(t, "failure", (*requests)[0].body["conclusion"])
+	output := (*requests)[0].body["output"].(map[string]interface{})
+	assert.Equal(t, "1 files without required owners", output["title"])
+	assert.Contains(t, output["summary"], "| file00.go |  | `@bob` |")
+	assert.Contains(t, output["summary"], "| file01.go | `@alice` |  |")
+	annotations := output["annotations"].([]interface{})
+	assert.Len(t, annotations, 50)
+	assert.Equal(t, map[string]interface{}{
+		"path":             "file00.go",
+		"start_line":       float64(1),
+		"end_line":         float64(1),
+		"annotation_level": "failure",
+		"title":            "Owners",
+		"message":          "No required owners\nOptional owners: @bob",
+	}, annotations[0])
+
+	assert.Equal(t, "PATCH", (*requests)[1].method)
+	assert.Equal(t, "/repos/org/repo/check-runs/42", (*requests)[1].uri)
+	annotations = (*requests)[1].body["output"].(map[string]interface{})["annotations"].([]interface{})
+	assert.Len(t, annotations, 10)
+	assert.Equal(t, "Required owners: @alice", annotations[9].(map[string]interface{})["message"])
+}
+
+func TestGetCompareCommitCount(t *testing.T) {
+	// A force push replaced 3 commits before it with 2 new commits.
+	url, _ := newTestRestServer(t, "Authorization", "bearer test-token", map[string]string{
+		"/repos/org/repo/compare/before...after": `{"ahead_by": 2, "behind_by": 3}`,
+	})
+	t.Setenv("GITHUB_API_URL", url)
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	actions := &GitHubActions{Repository: "org/repo", BaseRef: "before", HeadRef: "after"}
+	commitCount, err := actions.getCompareCommitCount()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, commitCount)
+}
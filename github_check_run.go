@@ -0,0 +1,168 @@
+package owners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	checkRunName = "owners"
+	// GitHub accepts at most 50 annotations per request.
+	maxNumAnnotationsPerRequest = 50
+	// GitHub rejects check run summaries longer than 65535 characters.
+	maxCheckRunSummaryLength = 65535
+)
+
+type checkRunAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type checkRunOutput struct {
+	Title       string               `json:"title"`
+	Summary     string               `json:"summary"`
+	Annotations []checkRunAnnotation `json:"annotations,omitempty"`
+}
+
+// WriteCheckRun publishes the owners of every changed file as a check run on
+// the head commit, with an annotation per file. If RequireOwners is set, the
+// check run fails when a changed file has no required owner.
+func (g *GitHubActions) WriteCheckRun(filePaths []string, results FindResults) error {
+	fileToOwners := ownersByFile(results)
+
+	var unowned []string
+	var annotations []checkRunAnnotation
+	for _, filePath := range filePaths {
+		var required, optional []string
+		for _, owner := range fileToOwners[filePath] {
+			if owner.Optional {
+				optional = append(optional, owner.Owner)
+			} else {
+				required = append(required, owner.Owner)
+			}
+		}
+
+		annotation := checkRunAnnotation{
+			Path:            filePath,
+			StartLine:       1,
+			EndLine:         1,
+			AnnotationLevel: "notice",
+			Title:           "Owners",
+		}
+		if len(required) > 0 {
+			annotation.Message = "Required owners: " + strings.Join(required, " ")
+		} else {
+			unowned = append(unowned, filePath)
+			annotation.Message = "No required owners"
+			if g.RequireOwners {
+				annotation.AnnotationLevel = "failure"
+			}
+		}
+		if len(optional) > 0 {
+			annotation.Message += "\nOptional owners: " + strings.Join(optional, " ")
+		}
+		annotations = append(annotations, annotation)
+	}
+
+	conclusion := "success"
+	title := fmt.Sprintf("%d owners for %d files", len(results.Owners), len(filePaths))
+	if g.RequireOwners && len(unowned) > 0 {
+		conclusion = "failure"
+		title = fmt.Sprintf("%d files without required owners", len(unowned))
+	}
+
+	output := checkRunOutput{
+		Title:   title,
+		Summary: writeCheckRunSummary(filePaths, fileToOwners),
+	}
+	if len(annotations) > maxNumAnnotationsPerRequest {
+		output.Annotations = annotations[:maxNumAnnotationsPerRequest]
+		annotations = annotations[maxNumAnnotationsPerRequest:]
+	} else {
+		output.Annotations = annotations
+		annotations = nil
+	}
+
+	checkRun := struct {
+		Id int64 `json:"id"`
+	}{}
+	err := g.restApi("POST", fmt.Sprintf("/repos/%s/check-runs", g.Repository), map[string]interface{}{
+		"name":       checkRunName,
+		"head_sha":   g.HeadRef,
+		"status":     "completed",
+		"conclusion": conclusion,
+		"output":     output,
+	}, &checkRun)
+	if err != nil {
+		return err
+	}
+
+	// Remaining annotations are appended by updating the check run.
+	for len(annotations) > 0 {
+		output.Annotations = annotations
+		if len(annotations) > maxNumAnnotationsPerRequest {
+			output.Annotations = annotations[:maxNumAnnotationsPerRequest]
+		}
+		annotations = annotations[len(output.Annotations):]
+
+		err := g.restApi("PATCH", fmt.Sprintf("/repos/%s/check-runs/%d", g.Repository, checkRun.Id), map[string]interface{}{
+			"output": output,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ownersByFile(results FindResults) map[string][]FindResult {
+	fileToOwners := make(map[string][]FindResult)
+	for _, owner := range results.Owners {
+		for _, filePath := range owner.FilePaths {
+			fileToOwners[filePath] = append(fileToOwners[filePath], owner)
+		}
+	}
+	return fileToOwners
+}
+
+func writeCheckRunSummary(filePaths []string, fileToOwners map[string][]FindResult) string {
+	w := &strings.Builder{}
+
+	writeLinef := func(format string, args ...interface{}) {
+		w.WriteString(fmt.Sprintf(format, args...))
+		w.WriteRune('\n')
+	}
+
+	sortedFilePaths := append([]string(nil), filePaths...)
+	sort.Strings(sortedFilePaths)
+
+	writeLinef("| File | Required owners | Optional owners |")
+	writeLinef("|-|-|-|")
+	for i, filePath := range sortedFilePaths {
+		var required, optional []string
+		for _, owner := range fileToOwners[filePath] {
+			// Backticks keep owners from being mentioned.
+			if owner.Optional {
+				optional = append(optional, "`"+owner.Owner+"`")
+			} else {
+				required = append(required, "`"+owner.Owner+"`")
+			}
+		}
+
+		row := fmt.Sprintf("| %s | %s | %s |", filePath, strings.Join(required, " "), strings.Join(optional, " "))
+		more := fmt.Sprintf("\n%d more files not shown.", len(sortedFilePaths)-i)
+		if w.Len()+len(row)+1+len(more) > maxCheckRunSummaryLength {
+			writeLinef(more)
+			break
+		}
+		writeLinef(row)
+	}
+
+	return w.String()
+}
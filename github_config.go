@@ -0,0 +1,206 @@
+package owners
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL     = "https://api.github.com"
+	defaultGitHubGraphQLURL = "https://api.github.com/graphql"
+)
+
+// GitHubConfig configures access to the GitHub API.
+type GitHubConfig struct {
+	// Base URL of the REST API, e.g. https://github.example.com/api/v3 on
+	// GitHub Enterprise Server.
+	APIURL string
+	// URL of the GraphQL API, e.g. https://github.example.com/api/graphql on
+	// GitHub Enterprise Server.
+	GraphQLURL string
+	Token      TokenSource
+}
+
+// TokenSource returns the token that authenticates requests to the GitHub API.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// StaticToken is a token that never changes, e.g. GITHUB_TOKEN or a personal
+// access token.
+type StaticToken string
+
+func (t StaticToken) Token() (string, error) {
+	if t == "" {
+		return "", errors.New("GITHUB_TOKEN is not set")
+	}
+	return string(t), nil
+}
+
+// GitHubConfigFromEnv reads the configuration from GITHUB_API_URL,
+// GITHUB_GRAPHQL_URL and GITHUB_TOKEN, which are set in GitHub Actions. If
+// GITHUB_APP_ID and GITHUB_APP_PRIVATE_KEY are set, requests are
+// authenticated as an installation of the GitHub App instead. The
+// installation is GITHUB_APP_INSTALLATION_ID, or else the installation on
+// GITHUB_REPOSITORY.
+func GitHubConfigFromEnv() GitHubConfig {
+	config := GitHubConfig{
+		APIURL:     os.Getenv("GITHUB_API_URL"),
+		GraphQLURL: os.Getenv("GITHUB_GRAPHQL_URL"),
+		Token:      StaticToken(os.Getenv("GITHUB_TOKEN")),
+	}
+	if config.APIURL == "" {
+		config.APIURL = defaultGitHubAPIURL
+	}
+	if config.GraphQLURL == "" {
+		config.GraphQLURL = defaultGitHubGraphQLURL
+	}
+
+	if appID := os.Getenv("GITHUB_APP_ID"); appID != "" {
+		installationID, _ := strconv.ParseInt(os.Getenv("GITHUB_APP_INSTALLATION_ID"), 10, 64)
+		config.Token = &GitHubAppTokenSource{
+			APIURL:         config.APIURL,
+			AppID:          appID,
+			PrivateKey:     []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY")),
+			InstallationID: installationID,
+			Repository:     os.Getenv("GITHUB_REPOSITORY"),
+		}
+	}
+
+	return config
+}
+
+// GitHubAppTokenSource authenticates as an installation of a GitHub App. A
+// JWT signed with the private key of the app is exchanged for an
+// installation token, which is reused until shortly before it expires.
+type GitHubAppTokenSource struct {
+	// Base URL of the REST API.
+	APIURL string
+	AppID  string
+	// PEM encoded private key of the app.
+	PrivateKey []byte
+	// Installation to authenticate as. If 0, the installation on Repository is used.
+	InstallationID int64
+	// Repository in owner/name form.
+	Repository string
+
+	// now returns the current time, for tests.
+	now func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (s *GitHubAppTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	if s.token != "" && now.Add(time.Minute).Before(s.expiresAt) {
+		return s.token, nil
+	}
+
+	jwt, err := s.jwt(now)
+	if err != nil {
+		return "", err
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+jwt)
+	header.Set("Accept", "application/vnd.github+json")
+	apiURL := strings.TrimSuffix(s.APIURL, "/")
+
+	installationID := s.InstallationID
+	if installationID == 0 {
+		if s.Repository == "" {
+			return "", errors.New("GitHub App installation id or repository is required")
+		}
+		installation := struct {
+			Id int64 `json:"id"`
+		}{}
+		if err := restRequest("GET", fmt.Sprintf("%s/repos/%s/installation", apiURL, s.Repository), header, nil, &installation); err != nil {
+			return "", fmt.Errorf("unable to find GitHub App installation: %w", err)
+		}
+		installationID = installation.Id
+	}
+
+	accessToken := struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}{}
+	if err := restRequest("POST", fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, installationID), header, nil, &accessToken); err != nil {
+		return "", fmt.Errorf("unable to create GitHub App installation token: %w", err)
+	}
+
+	s.token = accessToken.Token
+	s.expiresAt = accessToken.ExpiresAt
+	return s.token, nil
+}
+
+// jwt returns a JSON Web Token signed with RS256 that authenticates as the app.
+func (s *GitHubAppTokenSource) jwt(now time.Time) (string, error) {
+	key, err := parseRSAPrivateKey(s.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// Issued in the past to allow for clock drift, GitHub accepts at most 10 minutes until expiry.
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses a PKCS #1 or PKCS #8 PEM encoded RSA private key.
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid GitHub App private key: no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid GitHub App private key: not an RSA key")
+	}
+	return rsaKey, nil
+}
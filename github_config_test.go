@@ -0,0 +1,133 @@
+package owners
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubConfigFromEnv(t *testing.T) {
+	t.Setenv("GITHUB_API_URL", "")
+	t.Setenv("GITHUB_GRAPHQL_URL", "")
+	t.Setenv("GITHUB_TOKEN", "token")
+	t.Setenv("GITHUB_APP_ID", "")
+	assert.Equal(t, GitHubConfig{
+		APIURL:     "https://api.github.com",
+		GraphQLURL: "https://api.github.com/graphql",
+		Token:      StaticToken("token"),
+	}, GitHubConfigFromEnv())
+
+	t.Setenv("GITHUB_API_URL", "https://github.example.com/api/v3")
+	t.Setenv("GITHUB_APP_ID", "42")
+	t.Setenv("GITHUB_APP_PRIVATE_KEY", "key")
+	t.Setenv("GITHUB_APP_INSTALLATION_ID", "7")
+	t.Setenv("GITHUB_REPOSITORY", "org/repo")
+	assert.Equal(t, &GitHubAppTokenSource{
+		APIURL:         "https://github.example.com/api/v3",
+		AppID:          "42",
+		PrivateKey:     []byte("key"),
+		InstallationID: 7,
+		Repository:     "org/repo",
+	}, GitHubConfigFromEnv().Token)
+}
+
+func TestGitHubAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	verifyJWT := func(authorization string) (map[string]interface{}, error) {
+		parts := strings.Split(strings.TrimPrefix(authorization, "Bearer "), ".")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%d parts", len(parts))
+		}
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, err
+		}
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, err
+		}
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		var claims map[string]interface{}
+		err = json.Unmarshal(claimsJSON, &claims)
+		return claims, err
+	}
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		claims, err := verifyJWT(r.Header.Get("Authorization"))
+		if err != nil {
+			t.Errorf("invalid JWT: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		assert.Equal(t, map[string]interface{}{
+			"iat": float64(now.Add(-time.Minute).Unix()),
+			"exp": float64(now.Add(9 * time.Minute).Unix()),
+			"iss": "42",
+		}, claims)
+
+		switch r.URL.Path {
+		case "/repos/org/repo/installation":
+			w.Write([]byte(`{"id": 7}`))
+		case "/app/installations/7/access_tokens":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"token": "installation-token", "expires_at": "2024-01-01T13:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source := &GitHubAppTokenSource{
+		APIURL:     server.URL,
+		AppID:      "42",
+		PrivateKey: privateKey,
+		Repository: "org/repo",
+		now:        func() time.Time { return now },
+	}
+
+	token, err := source.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "installation-token", token)
+	assert.Equal(t, []string{"GET /repos/org/repo/installation", "POST /app/installations/7/access_tokens"}, requests)
+
+	// The token is reused until shortly before it expires.
+	now = now.Add(50 * time.Minute)
+	_, err = source.Token()
+	require.NoError(t, err)
+	assert.Len(t, requests, 2)
+
+	now = now.Add(9 * time.Minute)
+	_, err = source.Token()
+	require.NoError(t, err)
+	assert.Len(t, requests, 4)
+}
+
+func TestGitHubAppTokenSourceInvalidKey(t *testing.T) {
+	source := &GitHubAppTokenSource{AppID: "42", PrivateKey: []byte("not a key"), InstallationID: 7}
+	_, err := source.Token()
+	assert.EqualError(t, err, "invalid GitHub App private key: no PEM data found")
+}
@@ -0,0 +1,192 @@
+package owners
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	approvalStatusContext = "owners/approvals"
+)
+
+// GetApprovers returns the logins of reviewers whose latest review of the
+// pull request is an approval.
+func (g *GitHubActions) GetApprovers() ([]string, error) {
+	type reviewsPage struct {
+		Node struct {
+			LatestOpinionatedReviews struct {
+				Nodes []struct {
+					State  string `json:"state"`
+					Author struct {
+						Login string `json:"login"`
+					} `json:"author"`
+				} `json:"nodes"`
+				PageInfo pageInfo `json:"pageInfo"`
+			} `json:"latestOpinionatedReviews"`
+		} `json:"node"`
+	}
+	var pages []*reviewsPage
+	err := g.api().queryPages(`
+		query Approvers ($nodeId: ID!, $cursor: String) {
+			node(id: $nodeId) {
+				... on PullRequest {
+					latestOpinionatedReviews(first: 100, after: $cursor) {
+						nodes {
+							state
+							author {
+								login
+							}
+						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+					}
+				}
+			}
+		}`,
+		map[string]interface{}{
+			"nodeId": g.PullRequestNodeID,
+		},
+		func() (interface{}, func() pageInfo) {
+			page := &reviewsPage{}
+			pages = append(pages, page)
+			return page, func() pageInfo { return page.Node.LatestOpinionatedReviews.PageInfo }
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var approvers []string
+	for _, page := range pages {
+		for _, review := range page.Node.LatestOpinionatedReviews.Nodes {
+			if review.State == "APPROVED" {
+				approvers = append(approvers, "@"+review.Author.Login)
+			}
+		}
+	}
+	return approvers, nil
+}
+
+// GetTeamMembers returns the members of a team owner such as @org/team.
+func (g *GitHubActions) GetTeamMembers(team string) ([]string, error) {
+	org, slug, ok := strings.Cut(strings.TrimPrefix(team, "@"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid team %s", team)
+	}
+
+	members, found, err := g.api().getTeamMembers(org, slug)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("GitHub team %s/%s not found", org, slug)
+	}
+	return members, nil
+}
+
+// getTeamMembers returns the members of a team and whether the team exists.
+func (c *graphqlClient) getTeamMembers(org, slug string) ([]string, bool, error) {
+	type membersPage struct {
+		Organization *struct {
+			Team *struct {
+				Members struct {
+					Nodes []struct {
+						Login string `json:"login"`
+					} `json:"nodes"`
+					PageInfo pageInfo `json:"pageInfo"`
+				} `json:"members"`
+			} `json:"team"`
+		} `json:"organization"`
+	}
+	var pages []*membersPage
+	err := c.queryPages(`
+		query TeamMembers ($org: String!, $slug: String!, $cursor: String) {
+			organization(login: $org) {
+				team(slug: $slug) {
+					members(first: 100, after: $cursor) {
+						nodes {
+							login
+						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+					}
+				}
+			}
+		}`,
+		map[string]interface{}{
+			"org":  org,
+			"slug": slug,
+		},
+		func() (interface{}, func() pageInfo) {
+			page := &membersPage{}
+			pages = append(pages, page)
+			return page, func() pageInfo {
+				if page.Organization == nil || page.Organization.Team == nil {
+					return pageInfo{}
+				}
+				return page.Organization.Team.Members.PageInfo
+			}
+		},
+	)
+	// GitHub reports an unknown organization as an error.
+	if errors.Is(err, errGraphqlNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var members []string
+	for _, page := range pages {
+		if page.Organization == nil || page.Organization.Team == nil {
+			return nil, false, nil
+		}
+		for _, member := range page.Organization.Team.Members.Nodes {
+			members = append(members, "@"+member.Login)
+		}
+	}
+	return members, true, nil
+}
+
+// WriteApprovalStatus sets a commit status on the head commit and adds the
+// per-section table to the job summary.
+func (g *GitHubActions) WriteApprovalStatus(approvals Approvals) error {
+	state := "pending"
+	if approvals.Satisfied() {
+		state = "success"
+	}
+
+	err := g.restApi("POST", fmt.Sprintf("/repos/%s/statuses/%s", g.Repository, g.HeadRef), map[string]interface{}{
+		"state":       state,
+		"description": approvals.Description(),
+		"context":     approvalStatusContext,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	return writeStepSummary("### Owner approvals\n\n" + approvals.Markdown())
+}
+
+// writeStepSummary appends markdown to the summary of the current job.
+func writeStepSummary(markdown string) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(markdown + "\n")
+	return err
+}
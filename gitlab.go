@@ -0,0 +1,165 @@
+package owners
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GitLab rejects note bodies longer than 1000000 characters.
+const maxGitLabNoteLength = 1000000
+
+// GitLabCI reads a merge request pipeline from the predefined variables of
+// GitLab CI.
+type GitLabCI struct {
+	// Base URL of the v4 REST API, e.g. https://gitlab.com/api/v4.
+	APIURL          string
+	ProjectID       string
+	MergeRequestIID string
+	// Merge base of the source and target branches.
+	BaseRef string
+	HeadRef string
+	// Username of the merge request author, who is never notified.
+	Author string
+	// Owners that are never notified, e.g. bot accounts.
+	ExcludedOwners []string
+	MaxNumOwners   int
+	MaxNumFiles    int
+}
+
+func GetGitLabCI() (*GitLabCI, error) {
+	g := &GitLabCI{
+		APIURL:          os.Getenv("CI_API_V4_URL"),
+		ProjectID:       os.Getenv("CI_PROJECT_ID"),
+		MergeRequestIID: os.Getenv("CI_MERGE_REQUEST_IID"),
+		BaseRef:         os.Getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"),
+		HeadRef:         os.Getenv("CI_COMMIT_SHA"),
+	}
+	// Merged results pipelines run on a temporary merge commit of the source
+	// and target branches, which would count changes of the target branch
+	// since the merge base as changes of the merge request.
+	if sourceSHA := os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA"); sourceSHA != "" {
+		g.HeadRef = sourceSHA
+	}
+	if g.MergeRequestIID == "" {
+		return nil, fmt.Errorf("env var CI_MERGE_REQUEST_IID not set, not a merge request pipeline")
+	}
+	if g.APIURL == "" || g.ProjectID == "" || g.BaseRef == "" || g.HeadRef == "" {
+		return nil, fmt.Errorf("env vars CI_API_V4_URL, CI_PROJECT_ID, CI_MERGE_REQUEST_DIFF_BASE_SHA and CI_COMMIT_SHA must be set")
+	}
+
+	// The user who started the pipeline, e.g. by pushing to the source
+	// branch, is not necessarily the author of the merge request.
+	author, err := g.getAuthor()
+	if err != nil {
+		return nil, err
+	}
+	g.Author = author
+	return g, nil
+}
+
+// getAuthor returns the username of the merge request author.
+func (g *GitLabCI) getAuthor() (string, error) {
+	mergeRequest := struct {
+		Author struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}{}
+	path := fmt.Sprintf("/projects/%s/merge_requests/%s", url.PathEscape(g.ProjectID), g.MergeRequestIID)
+	if err := g.restApi("GET", path, nil, &mergeRequest); err != nil {
+		return "", err
+	}
+	return mergeRequest.Author.Username, nil
+}
+
+// Prepare fetches the merge base and the head of the source branch, which a
+// shallow clone usually lacks. Merged results pipelines only check out the
+// merge commit.
+func (g *GitLabCI) Prepare() error {
+	_, err := run("git", "-c", "protocol.version=2", "fetch", "--no-tags", "--depth", "1", "origin", g.BaseRef, g.HeadRef)
+	return err
+}
+
+// Differ returns the files changed by the merge request. BaseRef is already
+// the merge base, so the trees are compared directly without needing the
+// history in between.
+func (g *GitLabCI) Differ() Differ {
+	return gitTreeDiffer{baseRef: g.BaseRef, headRef: g.HeadRef}
+}
+
+// ExcludeOwners drops the author and excluded owners from results.
+func (g *GitLabCI) ExcludeOwners(results FindResults) FindResults {
+	return ExcludeAuthor(results, g.Author, g.ExcludedOwners)
+}
+
+// WriteComment adds or updates the owners notes on the merge request.
+func (g *GitLabCI) WriteComment(results FindResults) error {
+	return WriteComments(gitLabNotifier{ci: g}, results, CommentOptions{
+		BaseRef:      g.BaseRef,
+		HeadRef:      g.HeadRef,
+		MaxNumOwners: g.MaxNumOwners,
+		MaxNumFiles:  g.MaxNumFiles,
+	})
+}
+
+// gitLabNotifier posts notes on a merge request with the REST API.
+type gitLabNotifier struct {
+	ci *GitLabCI
+}
+
+func (n gitLabNotifier) notesPath() string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%s/notes", url.PathEscape(n.ci.ProjectID), n.ci.MergeRequestIID)
+}
+
+func (n gitLabNotifier) FindComments() ([]Comment, error) {
+	var comments []Comment
+	for page := 1; ; page++ {
+		var notes []struct {
+			Id   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		path := fmt.Sprintf("%s?sort=asc&order_by=created_at&per_page=100&page=%d", n.notesPath(), page)
+		if err := n.ci.restApi("GET", path, nil, &notes); err != nil {
+			return nil, err
+		}
+
+		for _, note := range notes {
+			if strings.HasPrefix(note.Body, commentHeader) {
+				comments = append(comments, Comment{ID: strconv.FormatInt(note.Id, 10), Body: note.Body})
+			}
+		}
+		if len(notes) < 100 {
+			return comments, nil
+		}
+	}
+}
+
+func (n gitLabNotifier) AddComment(body string) error {
+	return n.ci.restApi("POST", n.notesPath(), map[string]string{"body": body}, nil)
+}
+
+func (n gitLabNotifier) UpdateComment(comment Comment, body string) error {
+	return n.ci.restApi("PUT", n.notesPath()+"/"+comment.ID, map[string]string{"body": body}, nil)
+}
+
+func (n gitLabNotifier) DeleteComment(comment Comment) error {
+	return n.ci.restApi("DELETE", n.notesPath()+"/"+comment.ID, nil, nil)
+}
+
+func (n gitLabNotifier) MaxCommentLength() int {
+	return maxGitLabNoteLength
+}
+
+func (g *GitLabCI) restApi(method, path string, requestData interface{}, responseData interface{}) error {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return fmt.Errorf("GITLAB_TOKEN is not set")
+	}
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", token)
+
+	return restRequest(method, strings.TrimSuffix(g.APIURL, "/")+path, header, requestData, responseData)
+}
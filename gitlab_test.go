@@ -0,0 +1,112 @@
+package owners
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetGitLabCI(t *testing.T) {
+	// Someone other than the author started the pipeline.
+	apiURL, requests := newTestGitLabServer(t, map[string]string{
+		"/projects/7/merge_requests/12": `{"iid": 12, "author": {"username": "alice"}}`,
+	})
+	t.Setenv("CI_API_V4_URL", apiURL)
+	t.Setenv("CI_PROJECT_ID", "7")
+	t.Setenv("CI_MERGE_REQUEST_IID", "12")
+	t.Setenv("CI_MERGE_REQUEST_DIFF_BASE_SHA", "base")
+	t.Setenv("CI_COMMIT_SHA", "head")
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA", "")
+	t.Setenv("GITLAB_USER_LOGIN", "bob")
+
+	ci, err := GetGitLabCI()
+	require.NoError(t, err)
+	assert.Equal(t, &GitLabCI{
+		APIURL:          apiURL,
+		ProjectID:       "7",
+		MergeRequestIID: "12",
+		BaseRef:         "base",
+		HeadRef:         "head",
+		Author:          "alice",
+	}, ci)
+	assert.Len(t, *requests, 1)
+
+	results := ci.ExcludeOwners(FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+	}})
+	assert.Equal(t, FindResults{Owners: []FindResult{
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+	}}, results)
+
+	// Merged results pipelines run on a merge commit of the source branch.
+	t.Setenv("CI_COMMIT_SHA", "merge")
+	t.Setenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA", "source")
+	ci, err = GetGitLabCI()
+	require.NoError(t, err)
+	assert.Equal(t, "source", ci.HeadRef)
+
+	t.Setenv("CI_MERGE_REQUEST_IID", "")
+	_, err = GetGitLabCI()
+	assert.EqualError(t, err, "env var CI_MERGE_REQUEST_IID not set, not a merge request pipeline")
+}
+
+// newTestGitLabServer responds to GET requests with the response for their
+// request URI and records all requests.
+func newTestGitLabServer(t *testing.T, responses map[string]string) (string, *[]testRequest) {
+	t.Setenv("GITLAB_TOKEN", "test-token")
+	return newTestRestServer(t, "PRIVATE-TOKEN", "test-token", responses)
+}
+
+func TestGitLabWriteComment(t *testing.T) {
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@bob", FilePaths: []string{"b.go"}},
+	}}
+
+	notesURI := func(project string) string {
+		return "/projects/" + project + "/merge_requests/12/notes?sort=asc&order_by=created_at&per_page=100&page=1"
+	}
+
+	t.Run("add", func(t *testing.T) {
+		apiURL, requests := newTestGitLabServer(t, map[string]string{
+			notesURI("group%2Fproject"): `[{"id": 1, "body": "looks good"}]`,
+		})
+		ci := &GitLabCI{APIURL: apiURL, ProjectID: "group/project", MergeRequestIID: "12", BaseRef: "base", HeadRef: "head", Author: "alice"}
+
+		require.NoError(t, ci.WriteComment(ci.ExcludeOwners(results)))
+		require.Len(t, *requests, 2)
+		assert.Equal(t, "GET", (*requests)[0].method)
+		assert.Equal(t, notesURI("group%2Fproject"), (*requests)[0].uri)
+		assert.Equal(t, "POST", (*requests)[1].method)
+		body := (*requests)[1].body["body"].(string)
+		assert.True(t, strings.HasPrefix(body, commentHeader))
+		assert.Contains(t, body, "| @bob |")
+		assert.NotContains(t, body, "@alice")
+	})
+
+	t.Run("update and delete", func(t *testing.T) {
+		apiURL, requests := newTestGitLabServer(t, map[string]string{
+			notesURI("7"): fmt.Sprintf(`[{"id": 3, "body": %q}, {"id": 4, "body": %q}]`, commentHeader+"\nold", commentHeader+"\nold part"),
+		})
+		ci := &GitLabCI{APIURL: apiURL, ProjectID: "7", MergeRequestIID: "12", BaseRef: "base", HeadRef: "head"}
+
+		require.NoError(t, ci.WriteComment(results))
+		require.Len(t, *requests, 3)
+		assert.Equal(t, "PUT", (*requests)[1].method)
+		assert.Equal(t, "/projects/7/merge_requests/12/notes/3", (*requests)[1].uri)
+		assert.Equal(t, "DELETE", (*requests)[2].method)
+		assert.Equal(t, "/projects/7/merge_requests/12/notes/4", (*requests)[2].uri)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		apiURL, requests := newTestGitLabServer(t, map[string]string{notesURI("7"): `[]`})
+		ci := &GitLabCI{APIURL: apiURL, ProjectID: "7", MergeRequestIID: "12", BaseRef: "base", HeadRef: "head"}
+
+		require.NoError(t, ci.WriteComment(FindResults{}))
+		assert.Len(t, *requests, 1)
+	})
+}
@@ -0,0 +1,225 @@
+package owners
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateLimited is returned when the rate limit of the GitHub API, or of the
+// REST API of another forge, is exhausted. Retrying is pointless until the
+// limit resets, so callers can treat it as a soft failure.
+var ErrRateLimited = errors.New("API rate limit exceeded")
+
+// errGraphqlNotFound is returned when GitHub reports that a user,
+// organization or other node of a query does not exist. The data that was
+// found is still decoded.
+var errGraphqlNotFound = errors.New("not found")
+
+// Longest response body included in errors.
+const maxErrorBodyLength = 1024
+
+// Longest delay requested by a Retry-After header that the client waits for.
+const maxRetryAfter = time.Minute
+
+// graphqlClient sends queries to the GitHub GraphQL API. Server errors and
+// secondary rate limits of queries are retried with backoff. Mutations are
+// not retried since a failed request may still have been applied, e.g.
+// posting a duplicate comment.
+type graphqlClient struct {
+	url   string
+	token TokenSource
+
+	httpClient *http.Client
+	// Delay before the first retry, doubled for every further retry.
+	retryDelay time.Duration
+	maxRetries int
+	sleep      func(time.Duration)
+}
+
+func newGraphqlClient(url string, token TokenSource) *graphqlClient {
+	return &graphqlClient{
+		url:        url,
+		token:      token,
+		httpClient: &http.Client{},
+		retryDelay: time.Second,
+		maxRetries: 3,
+		sleep:      time.Sleep,
+	}
+}
+
+type graphqlError struct {
+	Type    string        `json:"type"`
+	Path    []interface{} `json:"path"`
+	Message string        `json:"message"`
+}
+
+// retryableError is a failed request that may succeed when sent again,
+// optionally after the delay requested by the server.
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// query runs query with variables and decodes its data into responseData.
+func (c *graphqlClient) query(query string, variables map[string]interface{}, responseData interface{}) error {
+	reqbody, err := json.Marshal(map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal query %s: %w", operationName(query), err)
+	}
+
+	delay := c.retryDelay
+	for retry := 0; ; retry++ {
+		err := c.do(reqbody, responseData)
+		var retryable *retryableError
+		if !errors.As(err, &retryable) || retry == c.maxRetries || isMutation(query) {
+			if err != nil {
+				return fmt.Errorf("graphql query %s: %w", operationName(query), err)
+			}
+			return nil
+		}
+
+		wait := delay
+		if retryable.retryAfter > 0 {
+			wait = retryable.retryAfter
+		}
+		c.sleep(wait)
+		delay *= 2
+	}
+}
+
+func (c *graphqlClient) do(reqbody []byte, responseData interface{}) error {
+	token, err := c.token.Token()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(reqbody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		err := fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+
+		switch {
+		case resp.Header.Get("X-RateLimit-Remaining") == "0":
+			return fmt.Errorf("%w: %s", ErrRateLimited, err)
+		case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+			// Secondary rate limits ask clients to wait before retrying.
+			if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 || strings.Contains(string(body), "secondary rate limit") {
+				return &retryableError{err: err, retryAfter: retryAfter}
+			}
+			return err
+		case resp.StatusCode >= 500:
+			return &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+		default:
+			return err
+		}
+	}
+
+	response := struct {
+		Data   interface{}    `json:"data"`
+		Errors []graphqlError `json:"errors"`
+	}{
+		Data: responseData,
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("error decoding json response: %w", err)
+	}
+
+	if len(response.Errors) > 0 {
+		switch response.Errors[0].Type {
+		case "RATE_LIMITED":
+			return fmt.Errorf("%w: %s", ErrRateLimited, response.Errors[0].Message)
+		case "NOT_FOUND":
+			return fmt.Errorf("%w: %s", errGraphqlNotFound, response.Errors[0].Message)
+		}
+		return fmt.Errorf("graphql error: %s", response.Errors[0].Message)
+	}
+
+	return nil
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// queryPages runs query for every page of a connection. The query takes the
+// end cursor of the previous page as $cursor. newPage returns where to decode
+// the next page and a function that returns the page info of the connection
+// once the page is decoded.
+func (c *graphqlClient) queryPages(query string, variables map[string]interface{}, newPage func() (interface{}, func() pageInfo)) error {
+	pageVariables := make(map[string]interface{})
+	for key, value := range variables {
+		pageVariables[key] = value
+	}
+	pageVariables["cursor"] = nil
+
+	for {
+		responseData, getPageInfo := newPage()
+		if err := c.query(query, pageVariables, responseData); err != nil {
+			return err
+		}
+		info := getPageInfo()
+		if !info.HasNextPage {
+			return nil
+		}
+		pageVariables["cursor"] = info.EndCursor
+	}
+}
+
+// parseRetryAfter parses a Retry-After header in seconds, capped at
+// maxRetryAfter.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	if seconds > int(maxRetryAfter/time.Second) {
+		return maxRetryAfter
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// operationName returns the name of the operation in query for errors.
+func operationName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) < 2 {
+		return "query"
+	}
+	name, _, _ := strings.Cut(fields[1], "(")
+	return name
+}
+
+// isMutation returns whether query is a mutation rather than a read query.
+func isMutation(query string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), "mutation")
+}
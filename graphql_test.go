@@ -0,0 +1,137 @@
+package owners
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGraphqlClient returns a client for a server that sends responses in
+// order, and records the delays the client sleeps for.
+func newTestGraphqlClient(t *testing.T, responses ...testResponse) (*graphqlClient, *[]time.Duration) {
+	url, _ := newTestServer(t, "Authorization", "bearer secret-token", func(req testRequest) testResponse {
+		if len(responses) == 0 {
+			t.Errorf("unexpected request %s", req.body["query"])
+			return testResponse{status: http.StatusInternalServerError}
+		}
+		response := responses[0]
+		responses = responses[1:]
+		return response
+	})
+
+	var sleeps []time.Duration
+	client := newGraphqlClient(url, StaticToken("secret-token"))
+	client.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	return client, &sleeps
+}
+
+func TestGraphqlClientRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		responses  []testResponse
+		wantErr    error
+		wantSleeps []time.Duration
+	}{
+		{
+			name: "server errors",
+			responses: []testResponse{
+				{status: http.StatusBadGateway},
+				{status: http.StatusServiceUnavailable},
+				{status: http.StatusOK, body: `{"data": {}}`},
+			},
+			wantSleeps: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			name: "secondary rate limit",
+			responses: []testResponse{
+				{status: http.StatusForbidden, header: map[string]string{"Retry-After": "30"}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+				{status: http.StatusOK, body: `{"data": {}}`},
+			},
+			wantSleeps: []time.Duration{30 * time.Second},
+		},
+		{
+			name: "long retry after",
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "86400"}},
+				{status: http.StatusOK, body: `{"data": {}}`},
+			},
+			wantSleeps: []time.Duration{time.Minute},
+		},
+		{
+			name: "primary rate limit",
+			responses: []testResponse{
+				{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0"}, body: `{"message": "API rate limit exceeded"}`},
+			},
+			wantErr: ErrRateLimited,
+		},
+		{
+			name: "primary rate limit error",
+			responses: []testResponse{
+				{status: http.StatusOK, body: `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded for user ID 1."}]}`},
+			},
+			wantErr: ErrRateLimited,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, sleeps := newTestGraphqlClient(t, test.responses...)
+			err := client.query("query Test { viewer { login } }", nil, nil)
+			if test.wantErr != nil {
+				assert.True(t, errors.Is(err, test.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantSleeps, *sleeps)
+		})
+	}
+}
+
+func TestGraphqlClientGivesUp(t *testing.T) {
+	var responses []testResponse
+	for i := 0; i < 4; i++ {
+		responses = append(responses, testResponse{status: http.StatusInternalServerError, body: "oops"})
+	}
+	client, sleeps := newTestGraphqlClient(t, responses...)
+
+	err := client.query("query Test { viewer { login } }", nil, nil)
+	assert.EqualError(t, err, "graphql query Test: 500 Internal Server Error: oops")
+	assert.NotContains(t, err.Error(), "secret-token")
+	assert.Len(t, *sleeps, 3)
+}
+
+func TestGraphqlClientDoesNotRetryMutations(t *testing.T) {
+	client, sleeps := newTestGraphqlClient(t, testResponse{status: http.StatusBadGateway})
+
+	err := client.query("mutation AddComment { addComment { clientMutationId } }", nil, nil)
+	assert.EqualError(t, err, "graphql query AddComment: 502 Bad Gateway: ")
+	assert.Empty(t, *sleeps)
+}
+
+func TestFindExistingCommentIdsPaginates(t *testing.T) {
+	requests := newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		if req.Variables["cursor"] == nil {
+			return map[string]interface{}{"node": map[string]interface{}{"comments": map[string]interface{}{
+				"nodes":    []interface{}{map[string]interface{}{"id": "1", "body": "lgtm"}},
+				"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "c1"},
+			}}}
+		}
+		return map[string]interface{}{"node": map[string]interface{}{"comments": map[string]interface{}{
+			"nodes":    []interface{}{map[string]interface{}{"id": "2", "body": commentHeader + "\nowners"}},
+			"pageInfo": map[string]interface{}{"hasNextPage": false},
+		}}}
+	})
+
+	actions := &GitHubActions{}
+	ids, err := actions.api().findExistingCommentIds("PR_1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ids)
+	require.Len(t, *requests, 2)
+	assert.Equal(t, "c1", (*requests)[1].Variables["cursor"])
+	assert.Equal(t, "PR_1", (*requests)[1].Variables["nodeId"])
+}
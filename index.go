@@ -0,0 +1,189 @@
+package owners
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// fileIndex is an owners file compiled for matching many paths.
+type fileIndex struct {
+	// Index of each section, in the order of OwnersFile.Sections.
+	sections []*sectionIndex
+}
+
+func newFileIndex(ownersFile *OwnersFile) *fileIndex {
+	index := &fileIndex{}
+	for _, section := range ownersFile.Sections {
+		index.sections = append(index.sections, newSectionIndex(section))
+	}
+	return index
+}
+
+// sectionIndex finds the last rule of a section that matches a path like
+// matchSection, without evaluating the glob of every rule. Rules are grouped
+// by the shape of their pattern:
+//
+//	foo/bar.go   literal path, looked up by path
+//	**/*.pb.go   extension, looked up by the extension of the file name
+//	foo/**       directory, looked up by every parent directory of the path
+//
+// Only the remaining globs are matched with doublestar, and only if they
+// come after the best rule found in the lookups.
+type sectionIndex struct {
+	// Kind of the pattern of each rule, which the cache stores so that
+	// patterns are not classified again.
+	kinds []ruleKind
+	// Last rule with each literal pattern.
+	literals map[string]int
+	// Rules with a **/*suffix pattern by the extension of the suffix, last
+	// rule first.
+	suffixes map[string][]suffixRule
+	// Last rule with each dir/** pattern by dir.
+	dirs map[string]int
+	// Remaining rules, last rule first.
+	globs []globRule
+}
+
+// ruleKind is the shape of a rule pattern that decides how it is indexed.
+type ruleKind uint8
+
+const (
+	ruleLiteral ruleKind = iota
+	ruleSuffix
+	ruleDir
+	ruleGlob
+	// A glob that is not valid, which is always evaluated so that its error
+	// is returned.
+	ruleInvalidGlob
+)
+
+type suffixRule struct {
+	index  int
+	suffix string
+}
+
+type globRule struct {
+	index   int
+	pattern string
+	// Literal directory prefix that every matching path starts with.
+	prefix string
+}
+
+func newSectionIndex(section *Section) *sectionIndex {
+	kinds := make([]ruleKind, len(section.Rules))
+	for i, rule := range section.Rules {
+		kinds[i] = patternKind(rule.Pattern)
+	}
+	return newSectionIndexOfKinds(section, kinds)
+}
+
+// patternKind classifies pattern for the index.
+func patternKind(pattern string) ruleKind {
+	if !hasGlobMeta(pattern) {
+		return ruleLiteral
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "**/*"); ok && !hasGlobMeta(suffix) && !strings.Contains(suffix, "/") {
+		// A file name ending with suffix has the same extension as the
+		// suffix, as long as the suffix has one.
+		if path.Ext(suffix) != "" {
+			return ruleSuffix
+		}
+	}
+	if dir, ok := strings.CutSuffix(pattern, "/**"); ok && !hasGlobMeta(dir) {
+		return ruleDir
+	}
+	if !doublestar.ValidatePattern(pattern) {
+		return ruleInvalidGlob
+	}
+	return ruleGlob
+}
+
+// newSectionIndexOfKinds indexes the rules of section, where kinds holds the
+// patternKind of each rule.
+func newSectionIndexOfKinds(section *Section, kinds []ruleKind) *sectionIndex {
+	index := &sectionIndex{
+		kinds:    kinds,
+		literals: make(map[string]int),
+		suffixes: make(map[string][]suffixRule),
+		dirs:     make(map[string]int),
+	}
+
+	for i, rule := range section.Rules {
+		pattern := rule.Pattern
+		switch kinds[i] {
+		case ruleLiteral:
+			index.literals[pattern] = i
+		case ruleSuffix:
+			suffix := strings.TrimPrefix(pattern, "**/*")
+			ext := path.Ext(suffix)
+			index.suffixes[ext] = append([]suffixRule{{index: i, suffix: suffix}}, index.suffixes[ext]...)
+		case ruleDir:
+			index.dirs[strings.TrimSuffix(pattern, "/**")] = i
+		case ruleGlob:
+			index.globs = append(index.globs, globRule{index: i, pattern: pattern, prefix: globPrefix(pattern)})
+		case ruleInvalidGlob:
+			index.globs = append(index.globs, globRule{index: i, pattern: pattern})
+		}
+	}
+
+	sort.Slice(index.globs, func(i, j int) bool {
+		return index.globs[i].index > index.globs[j].index
+	})
+	return index
+}
+
+// match returns the index of the last rule in the section that matches
+// relFilePath, or -1 if no rule matches.
+func (s *sectionIndex) match(relFilePath string) (int, error) {
+	best := -1
+	if i, ok := s.literals[relFilePath]; ok {
+		best = i
+	}
+
+	name := path.Base(relFilePath)
+	for _, rule := range s.suffixes[path.Ext(name)] {
+		if rule.index <= best {
+			break
+		}
+		if strings.HasSuffix(name, rule.suffix) {
+			best = rule.index
+			break
+		}
+	}
+
+	for dir := relFilePath; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if i, ok := s.dirs[dir]; ok && i > best {
+			best = i
+		}
+	}
+
+	for _, glob := range s.globs {
+		if glob.index <= best {
+			break
+		}
+		if !strings.HasPrefix(relFilePath, glob.prefix) {
+			continue
+		}
+		matched, err := doublestar.PathMatch(glob.pattern, relFilePath)
+		if err != nil {
+			return -1, err
+		}
+		if matched {
+			return glob.index, nil
+		}
+	}
+	return best, nil
+}
+
+// globPrefix returns the directories of pattern before its first wildcard,
+// including the trailing slash.
+func globPrefix(pattern string) string {
+	i := strings.IndexAny(pattern, `*?[{\`)
+	if i < 0 {
+		return pattern
+	}
+	return pattern[:strings.LastIndex(pattern[:i], "/")+1]
+}
@@ -0,0 +1,265 @@
+package owners
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/spf13/afero"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+const (
+	CheckNoOwners             = "no-owners"
+	CheckInvalidSectionHeader = "invalid-section-header"
+	CheckInvalidPattern       = "invalid-pattern"
+	CheckParentDirPattern     = "parent-dir-pattern"
+	CheckDuplicateSection     = "duplicate-section"
+	CheckShadowedRule         = "shadowed-rule"
+	CheckInvalidAlias         = "invalid-alias"
+	CheckInvalidOwner         = "invalid-owner"
+	CheckInvalidSetting       = "invalid-setting"
+	CheckNegatedRuleOwners    = "negated-rule-owners"
+)
+
+// Checks describes every check run by LintFile.
+var Checks = map[string]string{
+	CheckNoOwners:             "Rule has no owners and its section has no default owners.",
+	CheckInvalidSectionHeader: "Line looks like a section header but can not be parsed as one.",
+	CheckInvalidPattern:       "Rule pattern is not a valid glob.",
+	CheckParentDirPattern:     "Rule pattern contains a .. path element.",
+	CheckDuplicateSection:     "Section name is used more than once in the same file.",
+	CheckShadowedRule:         "Rule is shadowed by a later rule in the same section and never wins.",
+	CheckInvalidAlias:         "Alias can not be parsed, has no owners or is defined more than once in the same file.",
+	CheckInvalidOwner:         "Owner is not a @user, @org/team, @@role or email address.",
+	CheckInvalidSetting:       "Setting is unknown, repeated, or conflicts with another setting.",
+	CheckNegatedRuleOwners:    "Negated rule has owners, which are ignored.",
+}
+
+type Diagnostic struct {
+	FilePath string   `json:"file"`
+	Line     int      `json:"line"`
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", d.FilePath, d.Line, d.Severity, d.Message, d.Check)
+}
+
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func LintOwnersFiles(ownersFileName string) ([]Diagnostic, error) {
+	ownersFilePaths, err := FindAllOwnersFiles(ownersFileName)
+	if err != nil {
+		return nil, err
+	}
+	return lintOwnersFiles(afero.NewOsFs(), ownersFilePaths)
+}
+
+func lintOwnersFiles(fs afero.Fs, ownersFilePaths []string) ([]Diagnostic, error) {
+	var diagnostics []Diagnostic
+	for _, ownersFilePath := range ownersFilePaths {
+		file, err := fs.Open(ownersFilePath)
+		if err != nil {
+			return nil, err
+		}
+		fileDiagnostics, err := LintFile(ownersFilePath, file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to lint file %s: %w", ownersFilePath, err)
+		}
+		diagnostics = append(diagnostics, fileDiagnostics...)
+	}
+	return diagnostics, nil
+}
+
+// LintFile parses an owners file and reports problems that ParseFile
+// silently accepts.
+func LintFile(filePath string, r io.Reader) ([]Diagnostic, error) {
+	ownersFile, err := ParseFile(filePath, r)
+	if err != nil {
+		return nil, err
+	}
+	return lintOwnersFile(ownersFile), nil
+}
+
+func lintOwnersFile(ownersFile *OwnersFile) []Diagnostic {
+	var diagnostics []Diagnostic
+	report := func(line int, check string, severity Severity, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{
+			FilePath: ownersFile.Path,
+			Line:     line,
+			Check:    check,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	checkOwners := func(line int, owners []string) {
+		for _, owner := range owners {
+			if _, err := ParseOwner(owner); err != nil {
+				report(line, CheckInvalidOwner, SeverityError, "%s", err)
+			}
+		}
+	}
+
+	settingLines := make(map[string]int)
+	for _, setting := range ownersFile.Settings {
+		switch prevLine, ok := settingLines[setting.Name]; {
+		case setting.Name != SettingInherit && setting.Name != SettingNoParent:
+			report(setting.Line, CheckInvalidSetting, SeverityError, "unknown setting %q", setting.Name)
+		case ok:
+			report(setting.Line, CheckInvalidSetting, SeverityWarning, "setting %q is already set on line %d", setting.Name, prevLine)
+		default:
+			settingLines[setting.Name] = setting.Line
+		}
+	}
+	if inheritLine, ok := settingLines[SettingInherit]; ok {
+		if noParentLine, ok := settingLines[SettingNoParent]; ok {
+			report(inheritLine, CheckInvalidSetting, SeverityError, "set inherit conflicts with set noparent on line %d", noParentLine)
+		}
+	}
+
+	aliasLines := make(map[string]int)
+	for _, alias := range ownersFile.Aliases {
+		if prevLine, ok := aliasLines[alias.Name]; ok {
+			report(alias.Line, CheckInvalidAlias, SeverityError, "alias %q is already defined on line %d", alias.Name, prevLine)
+		} else {
+			aliasLines[alias.Name] = alias.Line
+		}
+		if len(alias.Owners) == 0 {
+			report(alias.Line, CheckInvalidAlias, SeverityError, "alias %q has no owners", alias.Name)
+		}
+		checkOwners(alias.Line, alias.Owners)
+	}
+
+	sectionLines := make(map[string]int)
+	for _, section := range ownersFile.Sections {
+		// The implicit default section has no header.
+		if section.Line > 0 {
+			if prevLine, ok := sectionLines[section.Name]; ok {
+				report(section.Line, CheckDuplicateSection, SeverityError, "section %q is already defined on line %d", section.Name, prevLine)
+			} else {
+				sectionLines[section.Name] = section.Line
+			}
+		}
+
+		var validRules []*Rule
+		for _, rule := range section.Rules {
+			line := ruleLine(rule)
+			if isSectionHeaderLike(line) {
+				report(rule.Line, CheckInvalidSectionHeader, SeverityError, "invalid section header %q", line)
+				continue
+			}
+			if isAliasLike(line) {
+				report(rule.Line, CheckInvalidAlias, SeverityError, "invalid alias %q", line)
+				continue
+			}
+
+			rawPattern := strings.TrimPrefix(tokenize(line)[0], "!")
+			if hasParentDirElement(rawPattern) {
+				report(rule.Line, CheckParentDirPattern, SeverityError, "pattern %q contains a parent directory reference", rawPattern)
+				continue
+			}
+			if !doublestar.ValidatePattern(rule.Pattern) {
+				report(rule.Line, CheckInvalidPattern, SeverityError, "invalid pattern %q", rawPattern)
+				continue
+			}
+			checkOwners(rule.Line, rule.Owners)
+			if rule.Negated && len(rule.Owners) > 0 {
+				report(rule.Line, CheckNegatedRuleOwners, SeverityWarning, "owners of negated rule %q are ignored", rawPattern)
+			}
+			if !rule.Negated && len(rule.Owners) == 0 && len(section.DefaultOwners) == 0 {
+				report(rule.Line, CheckNoOwners, SeverityError, "rule %q has no owners and section %q has no default owners", rawPattern, section.Name)
+			}
+			validRules = append(validRules, rule)
+		}
+
+		for i, earlier := range validRules {
+			for _, later := range validRules[i+1:] {
+				if patternCovers(later.Pattern, earlier.Pattern) {
+					report(earlier.Line, CheckShadowedRule, SeverityWarning, "rule %q is shadowed by rule %q on line %d", earlier.Pattern, later.Pattern, later.Line)
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+
+	return diagnostics
+}
+
+func hasParentDirElement(pattern string) bool {
+	for _, element := range strings.Split(pattern, "/") {
+		if element == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// patternCovers conservatively reports whether every path matched by pattern
+// is also matched by coveringPattern.
+func patternCovers(coveringPattern, pattern string) bool {
+	if coveringPattern == pattern || coveringPattern == "**" {
+		return true
+	}
+
+	// A literal pattern only matches itself.
+	if !hasGlobMeta(pattern) {
+		matched, err := doublestar.PathMatch(coveringPattern, pattern)
+		return err == nil && matched
+	}
+
+	// A literal directory followed by ** matches everything below it.
+	if strings.HasSuffix(coveringPattern, "/**") {
+		prefix := strings.TrimSuffix(coveringPattern, "**")
+		return !hasGlobMeta(prefix) && strings.HasPrefix(pattern, prefix)
+	}
+
+	return false
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[{\`)
+}
+
+// ruleLine returns the line of rule without its comment.
+func ruleLine(rule *Rule) string {
+	return strings.TrimSpace(strings.SplitN(rule.Raw, "#", 2)[0])
+}
+
+func isSectionHeaderLike(line string) bool {
+	return strings.HasPrefix(line, "[") || strings.HasPrefix(line, "^[")
+}
+
+func isAliasLike(line string) bool {
+	return strings.HasPrefix(line, "@alias")
+}
+
+// isMalformedRule reports whether rule is a broken section header, alias or
+// pattern, which Lint reports and Format keeps as written.
+func isMalformedRule(rule *Rule) bool {
+	line := ruleLine(rule)
+	return isSectionHeaderLike(line) || isAliasLike(line) || !doublestar.ValidatePattern(rule.Pattern)
+}
@@ -0,0 +1,114 @@
+package owners
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintFile(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected []Diagnostic
+	}{
+		{contents: "", expected: nil},
+		{contents: "foo.go @user1 # comment", expected: nil},
+		{
+			contents: "foo.go",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckNoOwners, Severity: SeverityError, Message: `rule "foo.go" has no owners and section "OWNERS" has no default owners`},
+			},
+		},
+		{contents: "[docs] @docs\nfoo.md", expected: nil},
+		{
+			contents: "[docs name] @docs\nfoo.md @user1",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckInvalidSectionHeader, Severity: SeverityError, Message: `invalid section header "[docs name] @docs"`},
+			},
+		},
+		{
+			contents: "foo/[bar.go @user1",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckInvalidPattern, Severity: SeverityError, Message: `invalid pattern "foo/[bar.go"`},
+			},
+		},
+		{
+			contents: "\n../foo.go @user1",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 2, Check: CheckParentDirPattern, Severity: SeverityError, Message: `pattern "../foo.go" contains a parent directory reference`},
+			},
+		},
+		{
+			contents: "[go]\nfoo.go @user1\n[docs]\nfoo.md @user1\n[go]\nbar.go @user1",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 5, Check: CheckDuplicateSection, Severity: SeverityError, Message: `section "go" is already defined on line 1`},
+			},
+		},
+		{
+			contents: "foo.go @user1\na/b.go @user1\na/*.go @user1\n* @user2\na/** @user3\n[other]\na/** @user4",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckShadowedRule, Severity: SeverityWarning, Message: `rule "foo.go" is shadowed by rule "*" on line 4`},
+				{FilePath: "OWNERS", Line: 2, Check: CheckShadowedRule, Severity: SeverityWarning, Message: `rule "a/b.go" is shadowed by rule "a/*.go" on line 3`},
+				{FilePath: "OWNERS", Line: 3, Check: CheckShadowedRule, Severity: SeverityWarning, Message: `rule "a/*.go" is shadowed by rule "a/**" on line 5`},
+			},
+		},
+		{contents: "@alias backend = @alice @bob\nfoo.go @backend", expected: nil},
+		{
+			contents: "@alias backend = @alice\n@alias backend =\n@alias front end = @bob\nfoo.go @backend",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 2, Check: CheckInvalidAlias, Severity: SeverityError, Message: `alias "backend" is already defined on line 1`},
+				{FilePath: "OWNERS", Line: 2, Check: CheckInvalidAlias, Severity: SeverityError, Message: `alias "backend" has no owners`},
+				{FilePath: "OWNERS", Line: 3, Check: CheckInvalidAlias, Severity: SeverityError, Message: `invalid alias "@alias front end = @bob"`},
+			},
+		},
+		{contents: "foo.go @org/platform-team jane@example.com", expected: nil},
+		{
+			contents: "@alias backend = @alice -bob\nfoo.go @backend @org/ @-carol",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckInvalidOwner, Severity: SeverityError, Message: `invalid owner "-bob"`},
+				{FilePath: "OWNERS", Line: 2, Check: CheckInvalidOwner, Severity: SeverityError, Message: `invalid owner "@org/"`},
+				{FilePath: "OWNERS", Line: 2, Check: CheckInvalidOwner, Severity: SeverityError, Message: `invalid owner "@-carol"`},
+			},
+		},
+		{contents: "set noparent\n* @user1", expected: nil},
+		{
+			contents: "set inherit\nset noparent\nset noparent\nset everything\n* @user1",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 1, Check: CheckInvalidSetting, Severity: SeverityError, Message: `set inherit conflicts with set noparent on line 2`},
+				{FilePath: "OWNERS", Line: 3, Check: CheckInvalidSetting, Severity: SeverityWarning, Message: `setting "noparent" is already set on line 2`},
+				{FilePath: "OWNERS", Line: 4, Check: CheckInvalidSetting, Severity: SeverityError, Message: `unknown setting "everything"`},
+			},
+		},
+		{
+			contents: "**/*.go @go\n!**/*_generated.go\n!*.pb.go @go",
+			expected: []Diagnostic{
+				{FilePath: "OWNERS", Line: 3, Check: CheckNegatedRuleOwners, Severity: SeverityWarning, Message: `owners of negated rule "*.pb.go" are ignored`},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := LintFile("OWNERS", bytes.NewBufferString(test.contents))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, got, "contents:\n%s", test.contents)
+	}
+}
+
+func TestLintOwnersFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte("root.go @root"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte("a.go\n"), 0644)
+	assert.NoError(t, err)
+
+	diagnostics, err := lintOwnersFiles(fs, []string{"OWNERS", "a/OWNERS"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Diagnostic{
+		{FilePath: "a/OWNERS", Line: 1, Check: CheckNoOwners, Severity: SeverityError, Message: `rule "a.go" has no owners and section "OWNERS" has no default owners`},
+	}, diagnostics)
+	assert.True(t, HasErrors(diagnostics))
+}
@@ -1,65 +1,268 @@
 package owners
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/spf13/afero"
 )
 
+// Matcher finds the owners of files. It caches the owners files it loads and
+// is safe for concurrent use.
 type Matcher struct {
 	fs             afero.Fs
 	ownersFileName string
-	ownersFiles    map[string]*OwnersFile
+	// Whether owners of all parent owners files are combined.
+	inherit bool
+	// Number of files that FindOwners matches concurrently.
+	parallelism int
+	// Optional cache of compiled owners files.
+	cache *ownersCache
+
+	// mu guards the caches below.
+	mu          sync.RWMutex
+	ownersFiles map[string]*OwnersFile
+	// Loads in progress by directory, which concurrent loads wait for.
+	loading map[string]*loadCall
+	// Aliases in scope for each directory, keyed by @name.
+	aliases map[string]map[string][]string
+	// Compiled rules of each loaded owners file.
+	indexes map[*OwnersFile]*fileIndex
+}
+
+// loadCall is a load of an owners file that other callers can wait for.
+type loadCall struct {
+	done chan struct{}
+	file *OwnersFile
+	err  error
+}
+
+// MatcherOption configures a Matcher.
+type MatcherOption func(*Matcher)
+
+// WithInheritance combines the owners matched in every owners file from the
+// directory of a file up to the root, instead of only using the closest
+// owners file that matched. Owners files with set noparent still stop the
+// search.
+func WithInheritance(inherit bool) MatcherOption {
+	return func(m *Matcher) {
+		m.inherit = inherit
+	}
 }
 
-func NewMatcher(ownersFileName string) *Matcher {
-	return newMatcherWithFs(ownersFileName, afero.NewOsFs())
+// WithParallelism sets the number of files that FindOwners matches
+// concurrently. Values below 2 match files one after another.
+func WithParallelism(parallelism int) MatcherOption {
+	return func(m *Matcher) {
+		m.parallelism = parallelism
+	}
+}
+
+func NewMatcher(ownersFileName string, opts ...MatcherOption) *Matcher {
+	return newMatcherWithFs(ownersFileName, afero.NewOsFs(), opts...)
 }
 
-func newMatcherWithFs(ownersFileName string, fs afero.Fs) *Matcher {
-	return &Matcher{
+// NewMatcherAt returns a matcher that reads owners files from the given git
+// revision instead of the working tree. Close releases the git process used
+// to read them.
+func NewMatcherAt(ownersFileName, rev string, opts ...MatcherOption) *Matcher {
+	return newMatcherWithFs(ownersFileName, newGitFs("", rev), opts...)
+}
+
+func newMatcherWithFs(ownersFileName string, fs afero.Fs, opts ...MatcherOption) *Matcher {
+	m := &Matcher{
 		fs:             fs,
 		ownersFileName: ownersFileName,
 		ownersFiles:    make(map[string]*OwnersFile),
+		loading:        make(map[string]*loadCall),
+		aliases:        make(map[string]map[string][]string),
+		indexes:        make(map[*OwnersFile]*fileIndex),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *Matcher) Close() error {
+	if closer, ok := m.fs.(io.Closer); ok {
+		return closer.Close()
 	}
+	return nil
 }
 
+// Load returns the owners file in dirPath, or an empty owners file if there
+// is none. Concurrent loads of the same directory read the file only once.
 func (m *Matcher) Load(dirPath string) (*OwnersFile, error) {
 	dirPath = filepath.Clean(dirPath)
-	if _, ok := m.ownersFiles[dirPath]; !ok {
-		ownersFilePath := filepath.Join(dirPath, m.ownersFileName)
-		if _, err := m.fs.Stat(ownersFilePath); err == nil {
-			file, err := m.fs.Open(ownersFilePath)
-			if err != nil {
-				return nil, err
-			}
-			ownersFile, err := ParseFile(file)
-			if err != nil {
-				return nil, fmt.Errorf("failed to parse file %s: %w", ownersFilePath, err)
-			}
-			m.ownersFiles[dirPath] = ownersFile
-		} else if errors.Is(err, os.ErrNotExist) {
-			// Use an empty owners file struct if no file exists.
-			m.ownersFiles[dirPath] = &OwnersFile{}
-		} else if err != nil {
+
+	m.mu.RLock()
+	ownersFile, ok := m.ownersFiles[dirPath]
+	m.mu.RUnlock()
+	if ok {
+		return ownersFile, nil
+	}
+
+	m.mu.Lock()
+	if ownersFile, ok := m.ownersFiles[dirPath]; ok {
+		m.mu.Unlock()
+		return ownersFile, nil
+	}
+	if call, ok := m.loading[dirPath]; ok {
+		m.mu.Unlock()
+		<-call.done
+		return call.file, call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	m.loading[dirPath] = call
+	m.mu.Unlock()
+
+	call.file, call.err = m.readOwnersFile(dirPath)
+
+	m.mu.Lock()
+	// Errors are not cached, so a later load tries again.
+	if call.err == nil {
+		m.ownersFiles[dirPath] = call.file
+	}
+	delete(m.loading, dirPath)
+	m.mu.Unlock()
+	close(call.done)
+
+	return call.file, call.err
+}
+
+func (m *Matcher) readOwnersFile(dirPath string) (*OwnersFile, error) {
+	ownersFilePath := filepath.Join(dirPath, m.ownersFileName)
+	if _, err := m.fs.Stat(ownersFilePath); errors.Is(err, os.ErrNotExist) {
+		// Use an empty owners file struct if no file exists.
+		return &OwnersFile{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := afero.ReadFile(m.fs, ownersFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if m.cache != nil {
+		if ownersFile, index, ok := m.cache.get(ownersFilePath, data); ok {
+			m.mu.Lock()
+			m.indexes[ownersFile] = index
+			m.mu.Unlock()
+			return ownersFile, nil
+		}
+	}
+
+	ownersFile, err := ParseFile(ownersFilePath, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", ownersFilePath, err)
+	}
+	if m.cache != nil {
+		// The cache is an optimization, failing to write it is not an error.
+		_ = m.cache.put(data, ownersFile, m.index(ownersFile))
+	}
+	return ownersFile, nil
+}
+
+// loadAliases returns the aliases in scope for owners files in dirPath: those
+// of the owners files in dirPath and its parent directories, where aliases of
+// nested directories override those of their parents.
+func (m *Matcher) loadAliases(dirPath string) (map[string][]string, error) {
+	dirPath = filepath.Clean(dirPath)
+	m.mu.RLock()
+	aliases, ok := m.aliases[dirPath]
+	m.mu.RUnlock()
+	if ok {
+		return aliases, nil
+	}
+
+	// Concurrent callers may compute the same aliases, which is harmless.
+	aliases = make(map[string][]string)
+	if dirPath != "." {
+		parentAliases, err := m.loadAliases(filepath.Dir(dirPath))
+		if err != nil {
 			return nil, err
 		}
+		for name, owners := range parentAliases {
+			aliases[name] = owners
+		}
+	}
+
+	ownersFile, err := m.Load(dirPath)
+	if err != nil {
+		return nil, err
 	}
-	return m.ownersFiles[dirPath], nil
+	for _, alias := range ownersFile.Aliases {
+		aliases["@"+alias.Name] = alias.Owners
+	}
+
+	m.mu.Lock()
+	m.aliases[dirPath] = aliases
+	m.mu.Unlock()
+	return aliases, nil
+}
+
+// index returns the compiled rules of ownersFile.
+func (m *Matcher) index(ownersFile *OwnersFile) *fileIndex {
+	m.mu.RLock()
+	index, ok := m.indexes[ownersFile]
+	m.mu.RUnlock()
+	if ok {
+		return index
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, ok := m.indexes[ownersFile]; ok {
+		return index
+	}
+	index = newFileIndex(ownersFile)
+	m.indexes[ownersFile] = index
+	return index
 }
 
 type MatchOwner struct {
-	Owner    string
-	Optional bool
+	Owner    string    `json:"owner"`
+	Type     OwnerType `json:"type,omitempty"`
+	Optional bool      `json:"optional"`
+	// Names of the sections whose rules matched the owner.
+	Sections []string `json:"sections"`
+	// Aliases that the owner was expanded from, e.g. @backend.
+	Aliases []string `json:"aliases,omitempty"`
 }
 
 func (m *Matcher) Match(filePath string) ([]MatchOwner, error) {
+	sectionMatches, err := m.MatchSections(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return matchOwners(sectionMatches), nil
+}
+
+type SectionMatch struct {
+	Name      string   `json:"name"`
+	Optional  bool     `json:"optional"`
+	Approvals int      `json:"approvals"`
+	Owners    []string `json:"owners"`
+	// Aliases that each owner was expanded from.
+	Aliases map[string][]string `json:"aliases,omitempty"`
+}
+
+// MatchSections returns the sections of the closest owners file that
+// matched owners for filePath, along with the owners of each section. If the
+// owners file inherits, the sections matched in parent directories up to the
+// root or set noparent are included as well.
+func (m *Matcher) MatchSections(filePath string) ([]SectionMatch, error) {
+	var allSectionMatches []SectionMatch
+	inherited := false
 	// Search in a/b/OWNERS -> a/OWNERS -> OWNERS
 	parts := strings.Split(filepath.Clean(filePath), string(os.PathSeparator))
 	for i := len(parts) - 1; i >= 0; i-- {
@@ -74,42 +277,95 @@ func (m *Matcher) Match(filePath string) ([]MatchOwner, error) {
 			return nil, err
 		}
 
-		matchedOwners, err := matchInFile(ownersFile, relFilePath)
+		aliases, err := m.loadAliases(dirPath)
+		if err != nil {
+			return nil, err
+		}
+
+		sectionMatches, excluded, err := matchSectionsInFile(ownersFile, m.index(ownersFile), aliases, relFilePath)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(matchedOwners) > 0 {
-			return matchedOwners, nil
+		allSectionMatches = append(allSectionMatches, sectionMatches...)
+		matched := len(sectionMatches) > 0 || excluded
+		if m.stopsSearch(ownersFile, matched, inherited) {
+			break
 		}
+		inherited = inherited || (matched && ownersFile.HasSetting(SettingInherit))
 	}
-	return nil, nil
+	return allSectionMatches, nil
 }
 
-func matchInFile(ownersFile *OwnersFile, relFilePath string) ([]MatchOwner, error) {
-	ownersToRequired := make(map[string]bool)
-	for _, section := range ownersFile.Sections {
-		for i := len(section.Rules) - 1; i >= 0; i-- {
-			rule := section.Rules[i]
+// stopsSearch reports whether parent directories of ownersFile are searched
+// for owners once it was evaluated. A file with set noparent always stops
+// the search. Otherwise the search stops at the first file that matched
+// owners or excluded the file with a negated rule, unless the matcher
+// inherits or inherited is set because a closer file that matched has set
+// inherit. Inheritance then continues up to the root or set noparent.
+func (m *Matcher) stopsSearch(ownersFile *OwnersFile, matched, inherited bool) bool {
+	if ownersFile.HasSetting(SettingNoParent) {
+		return true
+	}
+	return matched && !m.inherit && !inherited && !ownersFile.HasSetting(SettingInherit)
+}
 
-			matched, err := doublestar.PathMatch(rule.Pattern, relFilePath)
-			if err != nil {
-				return nil, err
-			}
-			if !matched {
-				continue
-			}
+func matchInFile(ownersFile *OwnersFile, index *fileIndex, aliases map[string][]string, relFilePath string) ([]MatchOwner, error) {
+	sectionMatches, _, err := matchSectionsInFile(ownersFile, index, aliases, relFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return matchOwners(sectionMatches), nil
+}
 
-			owners := rule.Owners
-			if len(owners) == 0 {
-				owners = section.DefaultOwners
-			}
+// matchSectionsInFile returns the sections in which a rule with owners
+// matched relFilePath, with aliases expanded. It also reports whether a
+// negated rule excluded relFilePath from a section.
+func matchSectionsInFile(ownersFile *OwnersFile, index *fileIndex, aliases map[string][]string, relFilePath string) ([]SectionMatch, bool, error) {
+	var sectionMatches []SectionMatch
+	excluded := false
+	for i, section := range ownersFile.Sections {
+		ruleIndex, err := index.sections[i].match(relFilePath)
+		if err != nil {
+			return nil, false, err
+		}
+		if ruleIndex < 0 {
+			continue
+		}
+		if section.Rules[ruleIndex].Negated {
+			excluded = true
+			continue
+		}
 
-			for _, owner := range owners {
-				ownersToRequired[owner] = ownersToRequired[owner] || !section.Optional
-			}
+		owners, ownerAliases := expandAliases(section.ruleOwners(section.Rules[ruleIndex]), aliases)
+		if len(owners) == 0 {
+			continue
+		}
 
-			break
+		sectionMatches = append(sectionMatches, SectionMatch{
+			Name:      section.Name,
+			Optional:  section.Optional,
+			Approvals: section.Approvals,
+			Owners:    owners,
+			Aliases:   ownerAliases,
+		})
+	}
+	return sectionMatches, excluded, nil
+}
+
+// matchOwners merges the owners of all matched sections. An owner is
+// required if any section that matched it is required.
+func matchOwners(sectionMatches []SectionMatch) []MatchOwner {
+	ownersToRequired := make(map[string]bool)
+	ownersToSections := make(map[string][]string)
+	ownersToAliases := make(map[string][]string)
+	for _, sectionMatch := range sectionMatches {
+		for _, owner := range sectionMatch.Owners {
+			ownersToRequired[owner] = ownersToRequired[owner] || !sectionMatch.Optional
+			ownersToSections[owner] = appendUnique(ownersToSections[owner], sectionMatch.Name)
+			for _, alias := range sectionMatch.Aliases[owner] {
+				ownersToAliases[owner] = appendUnique(ownersToAliases[owner], alias)
+			}
 		}
 	}
 
@@ -123,9 +379,85 @@ func matchInFile(ownersFile *OwnersFile, relFilePath string) ([]MatchOwner, erro
 	for _, owner := range sortedOwners {
 		matchedOwners = append(matchedOwners, MatchOwner{
 			Owner:    owner,
+			Type:     ownerType(owner),
 			Optional: !ownersToRequired[owner],
+			Sections: ownersToSections[owner],
+			Aliases:  ownersToAliases[owner],
 		})
 	}
 
-	return matchedOwners, nil
+	return matchedOwners
+}
+
+// expandAliases replaces aliases in owners with their owners, recursively.
+// It also returns the aliases that each expanded owner came from, where a
+// nested alias is attributed to the alias used in owners. An alias that
+// references itself is kept as a plain owner.
+func expandAliases(owners []string, aliases map[string][]string) ([]string, map[string][]string) {
+	var expanded []string
+	var ownerAliases map[string][]string
+
+	var expand func(owner, alias string, expanding map[string]bool)
+	expand = func(owner, alias string, expanding map[string]bool) {
+		aliasOwners, isAlias := aliases[owner]
+		if !isAlias || expanding[owner] {
+			expanded = appendUnique(expanded, owner)
+			if alias != "" {
+				if ownerAliases == nil {
+					ownerAliases = make(map[string][]string)
+				}
+				ownerAliases[owner] = appendUnique(ownerAliases[owner], alias)
+			}
+			return
+		}
+
+		if alias == "" {
+			alias = owner
+		}
+		expanding[owner] = true
+		for _, aliasOwner := range aliasOwners {
+			expand(aliasOwner, alias, expanding)
+		}
+		delete(expanding, owner)
+	}
+
+	for _, owner := range owners {
+		expand(owner, "", make(map[string]bool))
+	}
+	return expanded, ownerAliases
+}
+
+// matchSection returns the index of the last rule in the section that
+// matches relFilePath, or -1 if no rule matches. It evaluates every rule,
+// sectionIndex finds the same rule faster.
+func matchSection(section *Section, relFilePath string) (int, error) {
+	for i := len(section.Rules) - 1; i >= 0; i-- {
+		matched, err := doublestar.PathMatch(section.Rules[i].Pattern, relFilePath)
+		if err != nil {
+			return -1, err
+		}
+		if matched {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func (s *Section) ruleOwners(rule *Rule) []string {
+	if rule.Negated {
+		return nil
+	}
+	if len(rule.Owners) == 0 {
+		return s.DefaultOwners
+	}
+	return rule.Owners
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
 }
@@ -1,7 +1,12 @@
 package owners
 
 import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +27,7 @@ func TestMatcherMatch(t *testing.T) {
 		a/**/*.s @doublestar
 		b/*.s @singlestar
 		**/doublestar_prefix.s @doublestar_prefix
+		with\ space/*.md @org/space-team
 
 		^[optional]
 		root_optional.go @root_optional
@@ -52,31 +58,218 @@ func TestMatcherMatch(t *testing.T) {
 		{filePath: "a/does_not_exist.go", expected: nil},
 		{filePath: "a/b/c/d/does_not_exist.go", expected: nil},
 
-		{filePath: "root.go", expected: []MatchOwner{{Owner: "@root"}}},
-		{filePath: "root_optional.go", expected: []MatchOwner{{Owner: "@root_optional", Optional: true}}},
-		{filePath: "root_slash.go", expected: []MatchOwner{{Owner: "@root_slash"}}},
-		{filePath: "root_slash_unnormalized.go", expected: []MatchOwner{{Owner: "@root_slash_unnormalized"}}},
+		{filePath: "root.go", expected: []MatchOwner{{Owner: "@root", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "root_optional.go", expected: []MatchOwner{{Owner: "@root_optional", Type: OwnerUser, Optional: true, Sections: []string{"optional"}}}},
+		{filePath: "root_slash.go", expected: []MatchOwner{{Owner: "@root_slash", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "root_slash_unnormalized.go", expected: []MatchOwner{{Owner: "@root_slash_unnormalized", Type: OwnerUser, Sections: []string{"required"}}}},
 
-		{filePath: "a/a.go", expected: []MatchOwner{{Owner: "@a"}}},
-		{filePath: "a/a_optional.go", expected: []MatchOwner{{Owner: "@a_optional", Optional: true}}},
-		{filePath: "a/a_both.go", expected: []MatchOwner{{Owner: "@a"}, {Owner: "@a_optional", Optional: true}}},
-		{filePath: "a/a_slash.go", expected: []MatchOwner{{Owner: "@a_slash"}}},
+		{filePath: "a/a.go", expected: []MatchOwner{{Owner: "@a", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "a/a_optional.go", expected: []MatchOwner{{Owner: "@a_optional", Type: OwnerUser, Optional: true, Sections: []string{"optional"}}}},
+		{filePath: "a/a_both.go", expected: []MatchOwner{{Owner: "@a", Type: OwnerUser, Sections: []string{"required"}}, {Owner: "@a_optional", Type: OwnerUser, Optional: true, Sections: []string{"optional"}}}},
+		{filePath: "a/a_slash.go", expected: []MatchOwner{{Owner: "@a_slash", Type: OwnerUser, Sections: []string{"required"}}}},
 
-		{filePath: "doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix"}}},
-		{filePath: "a/doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix"}}},
-		{filePath: "a/b/c/d/doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix"}}},
+		{filePath: "doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "a/doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "a/b/c/d/doublestar_prefix.s", expected: []MatchOwner{{Owner: "@doublestar_prefix", Type: OwnerUser, Sections: []string{"required"}}}},
 
-		{filePath: "a/doublestar.s", expected: []MatchOwner{{Owner: "@doublestar"}}},
-		{filePath: "a/b/c/d/doublestar.s", expected: []MatchOwner{{Owner: "@doublestar"}}},
+		{filePath: "a/doublestar.s", expected: []MatchOwner{{Owner: "@doublestar", Type: OwnerUser, Sections: []string{"required"}}}},
+		{filePath: "a/b/c/d/doublestar.s", expected: []MatchOwner{{Owner: "@doublestar", Type: OwnerUser, Sections: []string{"required"}}}},
 
-		{filePath: "b/singlestar.s", expected: []MatchOwner{{Owner: "@singlestar"}}},
+		{filePath: "b/singlestar.s", expected: []MatchOwner{{Owner: "@singlestar", Type: OwnerUser, Sections: []string{"required"}}}},
 		{filePath: "b/c/d/singlestar.s", expected: nil},
+
+		{filePath: "with space/readme.md", expected: []MatchOwner{{Owner: "@org/space-team", Type: OwnerTeam, Sections: []string{"required"}}}},
+	}
+	for _, test := range tests {
+		got, err := matcher.Match(test.filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, got, "file: %s", test.filePath)
+	}
+}
+
+func TestMatcherAliases(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a/b", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte(`
+		@alias backend = @alice @org/backend
+		@alias platform = @backend @carol
+		@alias loop = @loop @dave
+		[required]
+		*.go @platform @alice
+		*.sh @loop
+		^[optional] @backend
+		*.md
+		`), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte(`
+		@alias backend = @bob
+		*.go @platform
+		`), 0644)
+	assert.NoError(t, err)
+
+	matcher := newMatcherWithFs("OWNERS", fs)
+
+	tests := []struct {
+		filePath string
+		expected []MatchOwner
+	}{
+		{filePath: "main.go", expected: []MatchOwner{
+			{Owner: "@alice", Type: OwnerUser, Sections: []string{"required"}, Aliases: []string{"@platform"}},
+			{Owner: "@carol", Type: OwnerUser, Sections: []string{"required"}, Aliases: []string{"@platform"}},
+			{Owner: "@org/backend", Type: OwnerTeam, Sections: []string{"required"}, Aliases: []string{"@platform"}},
+		}},
+		{filePath: "run.sh", expected: []MatchOwner{
+			{Owner: "@dave", Type: OwnerUser, Sections: []string{"required"}, Aliases: []string{"@loop"}},
+			{Owner: "@loop", Type: OwnerUser, Sections: []string{"required"}, Aliases: []string{"@loop"}},
+		}},
+		{filePath: "readme.md", expected: []MatchOwner{
+			{Owner: "@alice", Type: OwnerUser, Optional: true, Sections: []string{"optional"}, Aliases: []string{"@backend"}},
+			{Owner: "@org/backend", Type: OwnerTeam, Optional: true, Sections: []string{"optional"}, Aliases: []string{"@backend"}},
+		}},
+		// Aliases of nested owners files override those of parents.
+		{filePath: "a/main.go", expected: []MatchOwner{
+			{Owner: "@bob", Type: OwnerUser, Sections: []string{defaultSectionName}, Aliases: []string{"@platform"}},
+			{Owner: "@carol", Type: OwnerUser, Sections: []string{defaultSectionName}, Aliases: []string{"@platform"}},
+		}},
+	}
+	for _, test := range tests {
+		got, err := matcher.Match(test.filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, got, "file: %s", test.filePath)
+	}
+
+	results, err := matcher.FindOwners([]string{"main.go", "a/main.go"})
+	assert.NoError(t, err)
+	assert.Equal(t, FindResult{
+		Owner:     "@carol",
+		Type:      OwnerUser,
+		Sections:  []string{"OWNERS", "required"},
+		Aliases:   []string{"@platform"},
+		FilePaths: []string{"a/main.go", "main.go"},
+	}, results.Owners[2])
+	assert.Contains(t, results.String(), "@carol (via @platform):")
+}
+
+func TestMatcherInheritance(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("services/payments/api", 0755)
+	assert.NoError(t, err)
+	err = fs.MkdirAll("services/secrets/vault", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte("** @root"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "services/OWNERS", []byte("** @platform"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "services/payments/OWNERS", []byte("set inherit\n*.go @payments"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "services/payments/api/OWNERS", []byte("*.proto @api"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "services/secrets/OWNERS", []byte("set noparent\n**/*.go @security"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "services/secrets/vault/OWNERS", []byte("set inherit\n*.go @vault"), 0644)
+	assert.NoError(t, err)
+
+	owners := func(matchOwners []MatchOwner) []string {
+		var owners []string
+		for _, matchOwner := range matchOwners {
+			owners = append(owners, matchOwner.Owner)
+		}
+		return owners
+	}
+
+	tests := []struct {
+		filePath  string
+		closest   []string
+		inherited []string
+	}{
+		{filePath: "main.go", closest: []string{"@root"}, inherited: []string{"@root"}},
+		{filePath: "services/main.go", closest: []string{"@platform"}, inherited: []string{"@platform", "@root"}},
+		// set inherit continues the search after a match up to the root,
+		// past parents that do not inherit themselves.
+		{filePath: "services/payments/main.go", closest: []string{"@payments", "@platform", "@root"}, inherited: []string{"@payments", "@platform", "@root"}},
+		{filePath: "services/payments/api/api.proto", closest: []string{"@api"}, inherited: []string{"@api", "@platform", "@root"}},
+		{filePath: "services/payments/api/api.go", closest: []string{"@platform"}, inherited: []string{"@platform", "@root"}},
+		// set noparent stops the search, even without a match.
+		{filePath: "services/secrets/main.go", closest: []string{"@security"}, inherited: []string{"@security"}},
+		{filePath: "services/secrets/readme.md", closest: nil, inherited: nil},
+		{filePath: "services/secrets/vault/main.go", closest: []string{"@security", "@vault"}, inherited: []string{"@security", "@vault"}},
+	}
+	closestMatcher := newMatcherWithFs("OWNERS", fs)
+	inheritingMatcher := newMatcherWithFs("OWNERS", fs, WithInheritance(true))
+	for _, test := range tests {
+		got, err := closestMatcher.Match(test.filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, test.closest, owners(got), "file: %s", test.filePath)
+
+		got, err = inheritingMatcher.Match(test.filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, test.inherited, owners(got), "file: %s", test.filePath)
+	}
+
+	explanation, err := inheritingMatcher.Explain("services/secrets/main.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "services/secrets/OWNERS sets noparent, parent directories are not searched", explanation.StopReason)
+
+	explanation, err = inheritingMatcher.Explain("services/main.go")
+	assert.NoError(t, err)
+	assert.Len(t, explanation.OwnersFiles, 2)
+	assert.Equal(t, []string{"@platform", "@root"}, owners(explanation.Owners))
+	assert.Equal(t, "reached the root directory, owners of all matching files are combined", explanation.StopReason)
+}
+
+func TestMatcherNegation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte("** @root"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte(`
+		[go]
+		**/*.go @go
+		!**/*_generated.go
+		special_generated.go @special
+		^[docs]
+		*.go @docs
+		`), 0644)
+	assert.NoError(t, err)
+
+	matcher := newMatcherWithFs("OWNERS", fs)
+
+	tests := []struct {
+		filePath string
+		expected []MatchOwner
+	}{
+		{filePath: "a/main.go", expected: []MatchOwner{
+			{Owner: "@docs", Type: OwnerUser, Optional: true, Sections: []string{"docs"}},
+			{Owner: "@go", Type: OwnerUser, Sections: []string{"go"}},
+		}},
+		{filePath: "a/main_generated.go", expected: []MatchOwner{
+			{Owner: "@docs", Type: OwnerUser, Optional: true, Sections: []string{"docs"}},
+		}},
+		// Later rules still override a negated rule.
+		{filePath: "a/special_generated.go", expected: []MatchOwner{
+			{Owner: "@docs", Type: OwnerUser, Optional: true, Sections: []string{"docs"}},
+			{Owner: "@special", Type: OwnerUser, Sections: []string{"go"}},
+		}},
+		// A negated rule stops the search, so parent owners are not used.
+		{filePath: "a/b/c_generated.go", expected: nil},
+		{filePath: "a/readme.md", expected: []MatchOwner{{Owner: "@root", Type: OwnerUser, Sections: []string{defaultSectionName}}}},
 	}
 	for _, test := range tests {
 		got, err := matcher.Match(test.filePath)
 		assert.NoError(t, err)
 		assert.Equal(t, test.expected, got, "file: %s", test.filePath)
 	}
+
+	explanation, err := matcher.Explain("a/b/c_generated.go")
+	assert.NoError(t, err)
+	assert.True(t, explanation.OwnersFiles[1].Sections[1].Excluded)
+	assert.Equal(t, "a/OWNERS excluded the file, parent directories are not searched", explanation.StopReason)
+	assert.Contains(t, explanation.String(), "a/OWNERS:4: !**/*_generated.go: matched")
 }
 
 func TestMatcherLoad(t *testing.T) {
@@ -94,27 +287,27 @@ func TestMatcherLoad(t *testing.T) {
 	// Loads root OWNERS file with empty argument.
 	ownersFile, err := matcher.Load("")
 	assert.NoError(t, err)
-	assert.Equal(t, &OwnersFile{Sections: []*Section{
+	assert.Equal(t, &OwnersFile{Path: "OWNERS", Sections: []*Section{
 		{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
-			{Pattern: "root.go", Owners: []string{"@root"}},
+			{Pattern: "root.go", Owners: []string{"@root"}, Source: Source{Line: 1, Raw: "root.go @root"}},
 		}},
 	}}, ownersFile)
 
 	// Loads root OWNERS file with . argument.
 	ownersFile, err = matcher.Load(".")
 	assert.NoError(t, err)
-	assert.Equal(t, &OwnersFile{Sections: []*Section{
+	assert.Equal(t, &OwnersFile{Path: "OWNERS", Sections: []*Section{
 		{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
-			{Pattern: "root.go", Owners: []string{"@root"}},
+			{Pattern: "root.go", Owners: []string{"@root"}, Source: Source{Line: 1, Raw: "root.go @root"}},
 		}},
 	}}, ownersFile)
 
 	// Loads a/OWNERS file.
 	ownersFile, err = matcher.Load("a")
 	assert.NoError(t, err)
-	assert.Equal(t, &OwnersFile{Sections: []*Section{
+	assert.Equal(t, &OwnersFile{Path: "a/OWNERS", Sections: []*Section{
 		{Name: defaultSectionName, Approvals: 1, Rules: []*Rule{
-			{Pattern: "a.go", Owners: []string{"@a"}},
+			{Pattern: "a.go", Owners: []string{"@a"}, Source: Source{Line: 1, Raw: "a.go @a"}},
 		}},
 	}}, ownersFile)
 
@@ -128,3 +321,285 @@ func TestMatcherLoad(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &OwnersFile{}, ownersFile)
 }
+
+func TestMatcherExplain(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	err := fs.MkdirAll("a/b", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte("*.go @root"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte(`[required] @a_default
+		**/*.go @a_go
+		b/*.go
+		b/*.ts @a_ts
+
+		^[optional]
+		*.md @a_docs
+		`), 0644)
+	assert.NoError(t, err)
+
+	matcher := newMatcherWithFs("OWNERS", fs)
+
+	explanation, err := matcher.Explain("a/b/c.go")
+	assert.NoError(t, err)
+	assert.Equal(t, &Explanation{
+		FilePath: "a/b/c.go",
+		OwnersFiles: []ExplainedFile{
+			{Path: "a/b/OWNERS", RelFilePath: "c.go"},
+			{
+				Path:        "a/OWNERS",
+				Exists:      true,
+				RelFilePath: "b/c.go",
+				Sections: []ExplainedSection{
+					{Name: defaultSectionName},
+					{
+						Name: "required",
+						Line: 1,
+						Rules: []ExplainedRule{
+							{Pattern: "**/*.go", Owners: []string{"@a_go"}, Line: 2, Status: RuleNotEvaluated},
+							{Pattern: "b/*.go", Owners: []string{}, Line: 3, Status: RuleMatched},
+							{Pattern: "b/*.ts", Owners: []string{"@a_ts"}, Line: 4, Status: RuleNotMatched},
+						},
+						Matched:           true,
+						UsedDefaultOwners: true,
+						Owners:            []string{"@a_default"},
+					},
+					{
+						Name:     "optional",
+						Line:     6,
+						Optional: true,
+						Rules: []ExplainedRule{
+							{Pattern: "*.md", Owners: []string{"@a_docs"}, Line: 7, Status: RuleNotMatched},
+						},
+					},
+				},
+				Owners: []MatchOwner{{Owner: "@a_default", Type: OwnerUser, Sections: []string{"required"}}},
+			},
+		},
+		Owners:     []MatchOwner{{Owner: "@a_default", Type: OwnerUser, Sections: []string{"required"}}},
+		StopReason: "a/OWNERS matched owners, parent directories are not searched",
+	}, explanation)
+
+	explanation, err = matcher.Explain("a/b/c.txt")
+	assert.NoError(t, err)
+	assert.Len(t, explanation.OwnersFiles, 3)
+	assert.Nil(t, explanation.Owners)
+	assert.Equal(t, "reached the root directory without matching owners", explanation.StopReason)
+}
+
+func TestSectionIndexMatchesLinearSearch(t *testing.T) {
+	file, err := ParseFile("OWNERS", strings.NewReader(`
+		main.go @literal
+		a/b/c.go @literal_nested
+		**/*.go @go
+		**/*.pb.go @pb
+		**/*_test.go @test
+		a/** @a
+		a/b/** @ab
+		a/b/c.go @literal_last
+		*.md @md
+		a/*/c.go @single
+		**/gen/** @gen
+		with\ space/** @space
+		**/*.pb.go @pb_last
+		b/**/*.ts @ts
+		foo/[bar.go @invalid
+		a/b/x.go @literal_after_invalid
+		`))
+	assert.NoError(t, err)
+	section := file.Sections[0]
+	index := newSectionIndex(section)
+
+	filePaths := []string{
+		"main.go", "main.md", "readme.md", ".go", "x.pb.go", "a", "a/x.ts", "a/b", "a/b/c.go", "a/b/x.go",
+		"a/b/c_test.go", "a/x/c.go", "a/x/y/c.go", "ab/c.go", "gen/x.txt", "a/gen/b/x.txt", "with space/x",
+		"b/x.ts", "b/c/d/x.ts", "b/x.pb.go", "foo/bar.go", "c/d/e.txt",
+	}
+	for _, filePath := range filePaths {
+		expected, expectedErr := matchSection(section, filePath)
+		got, err := index.match(filePath)
+		assert.Equal(t, expectedErr, err, "file: %s", filePath)
+		assert.Equal(t, expected, got, "file: %s", filePath)
+	}
+}
+
+// newBenchmarkOwners returns a section with rules typical for a large
+// repository and the paths of a large generated change.
+func newBenchmarkOwners(b *testing.B) (*Section, []string) {
+	var rules strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&rules, "services/svc%d/** @team%d\n", i, i)
+		fmt.Fprintf(&rules, "services/svc%d/api/*.proto @api%d\n", i, i)
+		fmt.Fprintf(&rules, "services/svc%d/README.md @docs%d\n", i, i)
+	}
+	rules.WriteString("**/*.pb.go @codegen\n")
+	rules.WriteString("**/testdata/** @testdata\n")
+
+	file, err := ParseFile("OWNERS", strings.NewReader(rules.String()))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	var filePaths []string
+	for i := 0; i < 10000; i++ {
+		filePaths = append(filePaths, fmt.Sprintf("services/svc%d/pkg%d/file%d.go", i%100, i%7, i))
+	}
+	return file.Sections[0], filePaths
+}
+
+func BenchmarkMatchSection(b *testing.B) {
+	section, filePaths := newBenchmarkOwners(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, filePath := range filePaths {
+			if _, err := matchSection(section, filePath); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSectionIndex(b *testing.B) {
+	section, filePaths := newBenchmarkOwners(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index := newSectionIndex(section)
+		for _, filePath := range filePaths {
+			if _, err := index.match(filePath); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// newBenchmarkFs returns a file system with the rules of newBenchmarkOwners
+// in the root owners file.
+func newBenchmarkFs(b *testing.B) (afero.Fs, []string) {
+	section, filePaths := newBenchmarkOwners(b)
+	var rules strings.Builder
+	for _, rule := range section.Rules {
+		rules.WriteString(rule.Raw + "\n")
+	}
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "OWNERS", []byte(rules.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+	return fs, filePaths
+}
+
+func BenchmarkFindOwners(b *testing.B) {
+	fs, filePaths := newBenchmarkFs(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newMatcherWithFs("OWNERS", fs).FindOwners(filePaths); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLoad compares parsing and indexing the benchmark owners file
+// with reading it from the cache.
+func BenchmarkLoad(b *testing.B) {
+	fs, _ := newBenchmarkFs(b)
+	cacheDir := b.TempDir()
+	if _, err := newMatcherWithFs("OWNERS", fs, WithCache(cacheDir)).Load("."); err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name    string
+		options []MatcherOption
+	}{
+		{name: "parsed"},
+		{name: "cached", options: []MatcherOption{WithCache(cacheDir)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				matcher := newMatcherWithFs("OWNERS", fs, bc.options...)
+				ownersFile, err := matcher.Load(".")
+				if err != nil {
+					b.Fatal(err)
+				}
+				matcher.index(ownersFile)
+			}
+		})
+	}
+}
+
+// countingFs counts how often each file is opened.
+type countingFs struct {
+	afero.Fs
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (fs *countingFs) Open(name string) (afero.File, error) {
+	fs.mu.Lock()
+	fs.opens[name]++
+	fs.mu.Unlock()
+	// Give concurrent loads of the same file a chance to overlap.
+	time.Sleep(time.Millisecond)
+	return fs.Fs.Open(name)
+}
+
+func TestMatcherConcurrentUse(t *testing.T) {
+	fs := &countingFs{Fs: afero.NewMemMapFs(), opens: make(map[string]int)}
+
+	err := fs.MkdirAll("a/b", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "OWNERS", []byte("@alias go = @gopher\n**/*.go @go\n*.md @docs"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "a/OWNERS", []byte("b/*.ts @ts"), 0644)
+	assert.NoError(t, err)
+
+	var filePaths []string
+	for i := 0; i < 200; i++ {
+		filePaths = append(filePaths, fmt.Sprintf("a/b/file%d.go", i), fmt.Sprintf("a/b/file%d.ts", i), fmt.Sprintf("file%d.md", i))
+	}
+
+	expected, err := newMatcherWithFs("OWNERS", fs).FindOwners(filePaths)
+	assert.NoError(t, err)
+	fs.opens = make(map[string]int)
+
+	matcher := newMatcherWithFs("OWNERS", fs, WithParallelism(8))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := matcher.FindOwners(filePaths)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, got)
+
+			_, err = matcher.Explain("a/b/file.go")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Every owners file is read once, however many goroutines need it.
+	assert.Equal(t, map[string]int{"OWNERS": 1, "a/OWNERS": 1}, fs.opens)
+}
+
+func TestFindOwnersParallelError(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	err := afero.WriteFile(fs, "OWNERS", []byte("*.go @go\n[bad.go @bad"), 0644)
+	assert.NoError(t, err)
+
+	matcher := newMatcherWithFs("OWNERS", fs, WithParallelism(4))
+	_, err = matcher.FindOwners([]string{"a.go", "b.txt", "c.go", "d.txt"})
+	assert.Error(t, err)
+}
+
+func BenchmarkFindOwnersParallel(b *testing.B) {
+	fs, filePaths := newBenchmarkFs(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newMatcherWithFs("OWNERS", fs, WithParallelism(runtime.NumCPU())).FindOwners(filePaths); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package owners
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Comment is a comment on a change that was posted by a previous run.
+type Comment struct {
+	ID   string
+	Body string
+	// Version of the comment for platforms that require it for optimistic
+	// locking, such as Bitbucket Server.
+	Version int
+}
+
+// Notifier posts the owners comments on a change, e.g. a pull request, in a
+// code review platform.
+type Notifier interface {
+	// FindComments returns the owners comments posted by previous runs, in
+	// the order they were created.
+	FindComments() ([]Comment, error)
+	AddComment(body string) error
+	UpdateComment(comment Comment, body string) error
+	DeleteComment(comment Comment) error
+	// MaxCommentLength is the length of the longest comment body accepted by
+	// the platform.
+	MaxCommentLength() int
+}
+
+// noHTMLNotifier is implemented by notifiers of platforms that render
+// markdown comments without HTML, so the HTML comment that marks owners
+// comments would be visible.
+type noHTMLNotifier interface {
+	// commentMarker returns the first line of owners comments.
+	commentMarker() string
+}
+
+// CommentOptions controls how owners are rendered in comments.
+type CommentOptions struct {
+	BaseRef string
+	HeadRef string
+	// Owners are summarized instead of notified above this number, 0 to disable.
+	MaxNumOwners int
+	// Maximum number of files listed per owner, 0 to disable.
+	MaxNumFiles int
+}
+
+// ExcludeAuthor drops the author of a change and excludedOwners from
+// results, since authors do not review their own changes.
+func ExcludeAuthor(results FindResults, author string, excludedOwners []string) FindResults {
+	if author != "" {
+		excludedOwners = append([]string{author}, excludedOwners...)
+	}
+	return results.Exclude(excludedOwners)
+}
+
+// WriteComments renders results and posts them with n, updating the comments
+// of previous runs in place and deleting the ones no longer needed.
+func WriteComments(n Notifier, results FindResults, options CommentOptions) error {
+	writer := commentWriter{
+		baseRef:      options.BaseRef,
+		headRef:      options.HeadRef,
+		maxNumOwners: options.MaxNumOwners,
+		maxNumFiles:  options.MaxNumFiles,
+		maxLength:    n.MaxCommentLength(),
+		header:       commentHeader,
+	}
+	if plain, ok := n.(noHTMLNotifier); ok {
+		writer.header = plain.commentMarker()
+		writer.noHTML = true
+	}
+	comments := writer.write(results)
+
+	existing, err := n.FindComments()
+	if err != nil {
+		return err
+	}
+
+	// No comment exists and we don't need to notify any owners, so skip commenting.
+	if len(existing) == 0 && len(results.Owners) == 0 {
+		return nil
+	}
+
+	for i, comment := range comments {
+		if i < len(existing) {
+			err = n.UpdateComment(existing[i], comment)
+		} else {
+			err = n.AddComment(comment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remove comments left over from a previous run that needed more of them.
+	for i := len(comments); i < len(existing); i++ {
+		if err := n.DeleteComment(existing[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restRequest sends requestData as json and decodes the json response into
+// responseData. Responses other than 2xx are returned as errors, wrapping
+// ErrRateLimited if the API rate limit was hit.
+func restRequest(method, url string, header http.Header, requestData interface{}, responseData interface{}) error {
+	var reqbody io.Reader
+	if requestData != nil {
+		data, err := json.Marshal(requestData)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request %s %s: %w", method, url, err)
+		}
+		reqbody = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, url, reqbody)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	cl := &http.Client{}
+	resp, err := cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		err := fmt.Errorf("%s %s: %s\n%s", method, url, resp.Status, body)
+		if isRateLimited(resp, body) {
+			return fmt.Errorf("%w: %s", ErrRateLimited, err)
+		}
+		return err
+	}
+
+	if responseData == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(responseData); err != nil {
+		return fmt.Errorf("error decoding json response of %s %s: %w", method, url, err)
+	}
+	return nil
+}
+
+// isRateLimited reports whether a failed response was rejected by a primary
+// or secondary rate limit. REST requests are not retried, so both are
+// reported the same way.
+func isRateLimited(resp *http.Response, body []byte) bool {
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || bytes.Contains(body, []byte("rate limit")))
+}
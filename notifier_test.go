@@ -0,0 +1,228 @@
+package owners
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	comments  []Comment
+	nextID    int
+	maxLength int
+	calls     []string
+}
+
+func (n *fakeNotifier) FindComments() ([]Comment, error) {
+	return n.comments, nil
+}
+
+func (n *fakeNotifier) AddComment(body string) error {
+	n.nextID++
+	n.calls = append(n.calls, fmt.Sprintf("add %d", n.nextID))
+	return nil
+}
+
+func (n *fakeNotifier) UpdateComment(comment Comment, body string) error {
+	n.calls = append(n.calls, "update "+comment.ID)
+	return nil
+}
+
+func (n *fakeNotifier) DeleteComment(comment Comment) error {
+	n.calls = append(n.calls, "delete "+comment.ID)
+	return nil
+}
+
+func (n *fakeNotifier) MaxCommentLength() int {
+	return n.maxLength
+}
+
+func TestExcludeAuthor(t *testing.T) {
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go"}},
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+		{Owner: "@dependabot", FilePaths: []string{"a.go"}},
+	}}
+	assert.Equal(t, FindResults{Owners: []FindResult{
+		{Owner: "@bob", FilePaths: []string{"a.go"}},
+	}}, ExcludeAuthor(results, "alice", []string{"@dependabot"}))
+	assert.Equal(t, results, ExcludeAuthor(results, "", nil))
+}
+
+func TestWriteComments(t *testing.T) {
+	var owners []FindResult
+	for i := 0; i < 100; i++ {
+		owners = append(owners, FindResult{Owner: fmt.Sprintf("@owner%03d", i), FilePaths: []string{"a.go"}})
+	}
+	results := FindResults{Owners: owners}
+
+	tests := []struct {
+		name      string
+		comments  []Comment
+		results   FindResults
+		maxLength int
+		want      []string
+	}{
+		{
+			name:      "skip without comments and owners",
+			maxLength: maxGitHubCommentLength,
+		},
+		{
+			name:      "add",
+			results:   results,
+			maxLength: maxGitHubCommentLength,
+			want:      []string{"add 1"},
+		},
+		{
+			name:      "update existing when there are no owners",
+			comments:  []Comment{{ID: "a"}},
+			maxLength: maxGitHubCommentLength,
+			want:      []string{"update a"},
+		},
+		{
+			name:      "add parts",
+			comments:  []Comment{{ID: "a"}},
+			results:   results,
+			maxLength: 2000,
+			want:      []string{"update a", "add 1"},
+		},
+		{
+			name:      "delete extra parts",
+			comments:  []Comment{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+			results:   results,
+			maxLength: maxGitHubCommentLength,
+			want:      []string{"update a", "delete b", "delete c"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			n := &fakeNotifier{comments: test.comments, maxLength: test.maxLength}
+			err := WriteComments(n, test.results, CommentOptions{BaseRef: "base", HeadRef: "head"})
+			require.NoError(t, err)
+			assert.Equal(t, test.want, n.calls)
+		})
+	}
+}
+
+func TestGiteaNotifier(t *testing.T) {
+	url, calls := newTestRestServer(t, "Authorization", "token test-token", map[string]string{
+		"/api/v1/repos/org/repo/issues/5/comments?page=1&limit=50": fmt.Sprintf(`[{"id": 1, "body": "lgtm"}, {"id": 2, "body": %q}]`, commentHeader+"\nold"),
+	})
+	n := &GiteaNotifier{URL: url, Repository: "org/repo", PullRequest: 5, Token: "test-token"}
+
+	comments, err := n.FindComments()
+	require.NoError(t, err)
+	assert.Equal(t, []Comment{{ID: "2", Body: commentHeader + "\nold"}}, comments)
+
+	require.NoError(t, n.UpdateComment(comments[0], "new"))
+	require.NoError(t, n.AddComment("added"))
+	require.NoError(t, n.DeleteComment(comments[0]))
+	assert.Equal(t, []testRequest{
+		{method: "GET", uri: "/api/v1/repos/org/repo/issues/5/comments?page=1&limit=50"},
+		{method: "PATCH", uri: "/api/v1/repos/org/repo/issues/comments/2", body: map[string]interface{}{"body": "new"}},
+		{method: "POST", uri: "/api/v1/repos/org/repo/issues/5/comments", body: map[string]interface{}{"body": "added"}},
+		{method: "DELETE", uri: "/api/v1/repos/org/repo/issues/comments/2"},
+	}, *calls)
+}
+
+func TestBitbucketServerNotifier(t *testing.T) {
+	activity := func(id, version int, text string) string {
+		return fmt.Sprintf(`{"action": "COMMENTED", "commentAction": "ADDED", "comment": {"id": %d, "version": %d, "text": %q}}`, id, version, text)
+	}
+	url, calls := newTestRestServer(t, "Authorization", "Bearer test-token", map[string]string{
+		"/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/5/activities?start=0&limit=100": fmt.Sprintf(`{"values": [%s, {"action": "APPROVED"}], "isLastPage": false, "nextPageStart": 2}`,
+			activity(3, 1, bitbucketCommentMarker+"\npart 2")),
+		"/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/5/activities?start=2&limit=100": fmt.Sprintf(`{"values": [%s, %s], "isLastPage": true}`,
+			activity(2, 0, "lgtm"), activity(1, 4, bitbucketCommentMarker+"\npart 1")),
+	})
+	n := &BitbucketServerNotifier{URL: url, Repository: "PRJ/repo", PullRequest: 5, Token: "test-token"}
+
+	comments, err := n.FindComments()
+	require.NoError(t, err)
+	assert.Equal(t, []Comment{
+		{ID: "1", Body: bitbucketCommentMarker + "\npart 1", Version: 4},
+		{ID: "3", Body: bitbucketCommentMarker + "\npart 2", Version: 1},
+	}, comments)
+
+	require.NoError(t, n.UpdateComment(comments[0], "new"))
+	require.NoError(t, n.DeleteComment(comments[1]))
+	assert.Equal(t, []testRequest{
+		{method: "PUT", uri: "/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/5/comments/1", body: map[string]interface{}{"text": "new", "version": float64(4)}},
+		{method: "DELETE", uri: "/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/5/comments/3?version=1"},
+	}, (*calls)[2:])
+}
+
+func TestBitbucketServerNotifierComment(t *testing.T) {
+	url, calls := newTestRestServer(t, "Authorization", "Bearer test-token", map[string]string{
+		"/rest/api/1.0/projects/PRJ/repos/repo/pull-requests/5/activities?start=0&limit=100": `{"values": [], "isLastPage": true}`,
+	})
+	n := &BitbucketServerNotifier{URL: url, Repository: "PRJ/repo", PullRequest: 5, Token: "test-token"}
+
+	results := FindResults{Owners: []FindResult{
+		{Owner: "@alice", FilePaths: []string{"a.go", "b.go", "c.go", "d.go", "e.go"}},
+	}}
+	err := WriteComments(n, results, CommentOptions{BaseRef: "base", HeadRef: "head", MaxNumFiles: 4})
+	require.NoError(t, err)
+	require.Len(t, *calls, 2)
+
+	// Bitbucket Server escapes HTML, so comments must not contain any.
+	text := (*calls)[1].body["text"].(string)
+	assert.True(t, strings.HasPrefix(text, bitbucketCommentMarker+"\n"))
+	assert.NotContains(t, text, "<")
+	assert.Contains(t, text, "| @alice | ✅ | a.go, b.go, c.go, d.go, ...and 1 more not shown |")
+
+	// Neither is the summary of too many owners.
+	results.Owners = append(results.Owners, FindResult{Owner: "@bob", FilePaths: []string{"a.go"}})
+	err = WriteComments(n, results, CommentOptions{BaseRef: "base", HeadRef: "head", MaxNumOwners: 1})
+	require.NoError(t, err)
+	require.Len(t, *calls, 4)
+	text = (*calls)[3].body["text"].(string)
+	assert.NotContains(t, text, "<")
+	assert.Contains(t, text, "Summary of 2 owners:")
+}
+
+func TestRestRequestRateLimited(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    testResponse
+		rateLimited bool
+	}{
+		{
+			name:        "primary rate limit",
+			response:    testResponse{status: http.StatusForbidden, header: map[string]string{"X-RateLimit-Remaining": "0"}, body: `{"message": "API rate limit exceeded"}`},
+			rateLimited: true,
+		},
+		{
+			name:        "secondary rate limit",
+			response:    testResponse{status: http.StatusForbidden, header: map[string]string{"Retry-After": "30"}, body: `{"message": "You have exceeded a secondary rate limit."}`},
+			rateLimited: true,
+		},
+		{
+			name:        "too many requests",
+			response:    testResponse{status: http.StatusTooManyRequests},
+			rateLimited: true,
+		},
+		{
+			name:     "forbidden",
+			response: testResponse{status: http.StatusForbidden, body: `{"message": "Resource not accessible by integration"}`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			url, _ := newTestServer(t, "Authorization", "bearer test-token", func(req testRequest) testResponse {
+				return test.response
+			})
+			header := http.Header{}
+			header.Set("Authorization", "bearer test-token")
+
+			err := restRequest("POST", url+"/repos/org/repo/check-runs", header, map[string]string{"name": "owners"}, nil)
+			assert.Error(t, err)
+			assert.Equal(t, test.rateLimited, errors.Is(err, ErrRateLimited), err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package owners
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// OwnerType is the kind of identity that an owner refers to.
+type OwnerType string
+
+const (
+	// A user, e.g. @alice.
+	OwnerUser OwnerType = "user"
+	// A team or group, e.g. @org/platform-team or @group/subgroup on GitLab.
+	OwnerTeam OwnerType = "team"
+	// A user identified by email, e.g. jane@example.com.
+	OwnerEmail OwnerType = "email"
+	// A GitLab role, e.g. @@maintainer.
+	OwnerRole OwnerType = "role"
+)
+
+type Owner struct {
+	Name string    `json:"name"`
+	Type OwnerType `json:"type"`
+}
+
+// Handles may contain dashes and dots, but not start or end with them.
+const handlePattern = `[A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_])?`
+
+var (
+	userRe  = regexp.MustCompile(`^@` + handlePattern + `$`)
+	teamRe  = regexp.MustCompile(`^@` + handlePattern + `(?:/` + handlePattern + `)+$`)
+	roleRe  = regexp.MustCompile(`^@@\w+$`)
+	emailRe = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+// ParseOwner parses an owner token of a rule or section header.
+func ParseOwner(token string) (Owner, error) {
+	owner := Owner{Name: token}
+	switch {
+	case userRe.MatchString(token):
+		owner.Type = OwnerUser
+	case teamRe.MatchString(token):
+		owner.Type = OwnerTeam
+	case roleRe.MatchString(token):
+		owner.Type = OwnerRole
+	case emailRe.MatchString(token):
+		owner.Type = OwnerEmail
+	default:
+		return Owner{}, fmt.Errorf("invalid owner %q", token)
+	}
+	return owner, nil
+}
+
+// ownerType returns the type of owner, or an empty type if it is invalid.
+func ownerType(owner string) OwnerType {
+	parsed, err := ParseOwner(owner)
+	if err != nil {
+		return ""
+	}
+	return parsed.Type
+}
+
+// tokenize splits line at whitespace. Whitespace escaped with a backslash
+// is kept in its token, so patterns can match paths with spaces.
+func tokenize(line string) []string {
+	tokens := []string{}
+	var token strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case unicode.IsSpace(r):
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+		token.WriteRune(r)
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
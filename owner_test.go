@@ -0,0 +1,46 @@
+package owners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOwner(t *testing.T) {
+	tests := []struct {
+		token    string
+		expected OwnerType
+	}{
+		{token: "@alice", expected: OwnerUser},
+		{token: "@jane.doe", expected: OwnerUser},
+		{token: "@user-1_a", expected: OwnerUser},
+		{token: "@org/platform-team", expected: OwnerTeam},
+		{token: "@group/subgroup/team", expected: OwnerTeam},
+		{token: "@@maintainer", expected: OwnerRole},
+		{token: "jane@example.com", expected: OwnerEmail},
+		{token: "jane.doe+owners@mail.example.com", expected: OwnerEmail},
+
+		{token: "", expected: ""},
+		{token: "@", expected: ""},
+		{token: "alice", expected: ""},
+		{token: "@-alice", expected: ""},
+		{token: "@alice.", expected: ""},
+		{token: "@org/", expected: ""},
+		{token: "jane@localhost", expected: ""},
+	}
+	for _, test := range tests {
+		owner, err := ParseOwner(test.token)
+		if test.expected == "" {
+			assert.Error(t, err, "token: %s", test.token)
+			continue
+		}
+		assert.NoError(t, err, "token: %s", test.token)
+		assert.Equal(t, Owner{Name: test.token, Type: test.expected}, owner)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{}, tokenize(""))
+	assert.Equal(t, []string{"a.go", "@alice", "@bob"}, tokenize(" a.go\t@alice  @bob "))
+	assert.Equal(t, []string{`my\ docs/**`, "@alice"}, tokenize(`my\ docs/** @alice`))
+}
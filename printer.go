@@ -0,0 +1,203 @@
+package owners
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Format parses the owners file in src and returns it in canonical form.
+func Format(path string, src []byte) ([]byte, error) {
+	file, err := ParseFile(path, bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := FormatFile(&buf, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// FormatFile writes an owners file in canonical form: settings and aliases
+// first, one blank line between groups, section headers as
+// ^[name][approvals] @defaults, normalized patterns, sorted owners and owner
+// columns aligned within each group of consecutive rules. Malformed rules
+// that Lint reports are written as they are.
+func FormatFile(w io.Writer, file *OwnersFile) error {
+	p := &printer{w: bufio.NewWriter(w)}
+
+	for _, setting := range file.Settings {
+		p.separate(setting.Source, false)
+		p.writeDoc(setting.Doc)
+		p.writeLine("set "+setting.Name, setting.Comment)
+		p.prevLine = setting.Line
+	}
+	for _, alias := range file.Aliases {
+		p.separate(alias.Source, false)
+		p.writeDoc(alias.Doc)
+		p.writeLine(formatAlias(alias), alias.Comment)
+		p.prevLine = alias.Line
+	}
+	// Settings and aliases are separated from the sections and rules.
+	p.blankLineRequired = len(file.Settings) > 0 || len(file.Aliases) > 0
+
+	for i, section := range file.Sections {
+		if i > 0 || section.Line > 0 || section.Name != defaultSectionName {
+			p.separate(section.Source, true)
+			p.writeDoc(section.Doc)
+			p.writeLine(formatSectionHeader(section), section.Comment)
+			p.prevLine = section.Line
+		}
+
+		var group []*Rule
+		for _, rule := range section.Rules {
+			if len(group) > 0 && (len(rule.Doc) > 0 || p.hasBlankLineBefore(rule.Source)) {
+				p.writeRules(group)
+				group = nil
+			}
+			if len(group) == 0 {
+				p.separate(rule.Source, false)
+				p.writeDoc(rule.Doc)
+			}
+			group = append(group, rule)
+			p.prevLine = rule.Line
+		}
+		p.writeRules(group)
+	}
+
+	if len(file.TrailingComments) > 0 {
+		if p.written {
+			p.writeLine("", "")
+		}
+		p.writeDoc(file.TrailingComments)
+	}
+
+	if p.err != nil {
+		return p.err
+	}
+	return p.w.Flush()
+}
+
+type printer struct {
+	w        *bufio.Writer
+	err      error
+	written  bool
+	prevLine int
+	// Whether the next element is preceded by a blank line regardless of
+	// the source.
+	blankLineRequired bool
+}
+
+func (p *printer) writeLine(line, comment string) {
+	if comment != "" {
+		if line != "" {
+			line += " "
+		}
+		line += comment
+	}
+	if p.err == nil {
+		_, p.err = p.w.WriteString(line + "\n")
+	}
+	p.written = true
+}
+
+// writeDoc writes comment lines, with at most one blank line in a row.
+func (p *printer) writeDoc(doc []string) {
+	for i, line := range doc {
+		if line == "" && i > 0 && doc[i-1] == "" {
+			continue
+		}
+		p.writeLine(line, "")
+	}
+}
+
+// separate writes a blank line before an element if one is required, or if
+// the element was preceded by one in the source.
+func (p *printer) separate(source Source, required bool) {
+	if p.written && (required || p.blankLineRequired || p.hasBlankLineBefore(source)) {
+		p.writeLine("", "")
+	}
+	p.blankLineRequired = false
+}
+
+func (p *printer) hasBlankLineBefore(source Source) bool {
+	if source.Line == 0 || p.prevLine == 0 {
+		return false
+	}
+	return source.Line-len(source.Doc)-1 > p.prevLine
+}
+
+func (p *printer) writeRules(rules []*Rule) {
+	width := 0
+	for _, rule := range rules {
+		if n := len(formatPattern(rule)); n > width && !isMalformedRule(rule) {
+			width = n
+		}
+	}
+
+	for _, rule := range rules {
+		// Formatting could change the meaning of lines that did not parse
+		// as intended.
+		if isMalformedRule(rule) {
+			p.writeLine(rule.Raw, "")
+			continue
+		}
+		pattern := formatPattern(rule)
+		owners := canonicalOwners(rule.Owners)
+		line := pattern
+		if len(owners) > 0 {
+			line = fmt.Sprintf("%-*s %s", width, pattern, strings.Join(owners, " "))
+		}
+		p.writeLine(line, rule.Comment)
+	}
+}
+
+func formatPattern(rule *Rule) string {
+	pattern := normalizePattern(rule.Pattern)
+	if rule.Negated {
+		pattern = "!" + pattern
+	}
+	return pattern
+}
+
+func formatSectionHeader(section *Section) string {
+	var s strings.Builder
+	if section.Optional {
+		s.WriteString("^")
+	}
+	s.WriteString("[" + section.Name + "]")
+	if section.Approvals > 1 {
+		s.WriteString("[" + strconv.Itoa(section.Approvals) + "]")
+	}
+	for _, owner := range canonicalOwners(section.DefaultOwners) {
+		s.WriteString(" " + owner)
+	}
+	return s.String()
+}
+
+func formatAlias(alias *Alias) string {
+	line := "@alias " + alias.Name + " ="
+	for _, owner := range canonicalOwners(alias.Owners) {
+		line += " " + owner
+	}
+	return line
+}
+
+func canonicalOwners(owners []string) []string {
+	seen := make(map[string]bool)
+	var sortedOwners []string
+	for _, owner := range owners {
+		if !seen[owner] {
+			seen[owner] = true
+			sortedOwners = append(sortedOwners, owner)
+		}
+	}
+	sort.Strings(sortedOwners)
+	return sortedOwners
+}
@@ -0,0 +1,121 @@
+package owners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected string
+	}{
+		{contents: "", expected: ""},
+		{contents: "\n\n", expected: ""},
+		{contents: "foo.go @user1", expected: "foo.go @user1\n"},
+		{
+			contents: `
+				# Go files.
+				foo.go   @user2 @user1 @user2 # Trailing comment.
+				/bar/baz.go @user3
+				./qux/   @user4
+
+
+				readme.md @docs
+			`,
+			expected: `# Go files.
+foo.go     @user1 @user2 # Trailing comment.
+bar/baz.go @user3
+qux        @user4
+
+readme.md @docs
+`,
+		},
+		{
+			contents: `
+				* @root
+				[go][1] @go2 @go1
+				# Generated.
+				*.pb.go
+				*.go  @go
+				^[docs][2]
+				*.md @docs # Docs.
+
+				# The end.
+			`,
+			expected: `* @root
+
+[go] @go1 @go2
+# Generated.
+*.pb.go
+*.go    @go
+
+^[docs][2]
+*.md @docs # Docs.
+
+# The end.
+`,
+		},
+		{
+			contents: `
+				* @backend
+				# Backend team.
+				@alias   backend=@bob @alice # Trailing comment.
+				@alias frontend = @carol
+			`,
+			expected: `# Backend team.
+@alias backend = @alice @bob # Trailing comment.
+@alias frontend = @carol
+
+* @backend
+`,
+		},
+		{
+			contents: `* @root
+				set   noparent # Only the root owns files.
+			`,
+			expected: `set noparent # Only the root owns files.
+
+* @root
+`,
+		},
+		{
+			contents: `
+				**/*.go @go
+				!/**/*_generated.go
+			`,
+			expected: `**/*.go            @go
+!**/*_generated.go
+`,
+		},
+		{
+			contents: `
+				*.go @go
+				[docs name] @docs
+				@alias   = @bob
+				/a/[b   @b # Unclosed.
+			`,
+			expected: `*.go @go
+[docs name] @docs
+@alias   = @bob
+/a/[b   @b # Unclosed.
+`,
+		},
+		// Comment blocks separated by a blank line stay separate.
+		{contents: "# Copyright\n\n*.go @a\n", expected: "# Copyright\n\n*.go @a\n"},
+		{contents: "# Copyright\n\nset noparent\n\n*.go @a\n", expected: "# Copyright\n\nset noparent\n\n*.go @a\n"},
+		{contents: "# Copyright\n\n# Go files.\n*.go @a\n", expected: "# Copyright\n\n# Go files.\n*.go @a\n"},
+		{contents: "# Copyright\n\n\n# Go files.\n\n\n*.go @a\n", expected: "# Copyright\n\n# Go files.\n\n*.go @a\n"},
+	}
+	for _, test := range tests {
+		got, err := Format("OWNERS", []byte(test.contents))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, string(got), "contents:\n%s", test.contents)
+
+		// Formatting is idempotent.
+		again, err := Format("OWNERS", got)
+		assert.NoError(t, err)
+		assert.Equal(t, string(got), string(again), "contents:\n%s", got)
+	}
+}
@@ -0,0 +1,247 @@
+package owners
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolvedOwner is an owner looked up on GitHub.
+type ResolvedOwner struct {
+	Owner string `json:"owner"`
+	Team  bool   `json:"team"`
+	// Whether the user or team exists.
+	Exists bool `json:"exists"`
+	// Members of a team owner.
+	Members []string `json:"members,omitempty"`
+	// Permission of the owner on the repository, e.g. WRITE, or empty
+	// without access.
+	Permission string `json:"permission,omitempty"`
+	// Why the owner can not review changes, empty for valid owners.
+	Problem string `json:"problem,omitempty"`
+}
+
+// Valid reports whether the owner can review changes to the repository.
+func (o ResolvedOwner) Valid() bool {
+	return o.Problem == ""
+}
+
+func (o ResolvedOwner) String() string {
+	if o.Valid() {
+		return fmt.Sprintf("%s: ok", o.Owner)
+	}
+	return fmt.Sprintf("%s: %s", o.Owner, o.Problem)
+}
+
+// OwnerResolver looks up owners on GitHub to validate them and expand teams
+// into their members. Lookups are cached.
+type OwnerResolver struct {
+	client *graphqlClient
+	// Repository in owner/name form that owners need write access to.
+	repository string
+	cache      map[string]ResolvedOwner
+}
+
+func NewOwnerResolver(config GitHubConfig, repository string) *OwnerResolver {
+	return &OwnerResolver{
+		client:     newGraphqlClient(config.GraphQLURL, config.Token),
+		repository: repository,
+		cache:      make(map[string]ResolvedOwner),
+	}
+}
+
+// OwnerResolver returns a resolver for owners of the repository of the event.
+func (g *GitHubActions) OwnerResolver() *OwnerResolver {
+	return &OwnerResolver{
+		client:     g.api(),
+		repository: g.Repository,
+		cache:      make(map[string]ResolvedOwner),
+	}
+}
+
+// ValidateOwners resolves every owner in results, sorted by owner. Email and
+// role owners can not be looked up on GitHub and are skipped.
+func (r *OwnerResolver) ValidateOwners(results FindResults) ([]ResolvedOwner, error) {
+	seen := make(map[string]bool)
+	var resolved []ResolvedOwner
+	for _, result := range results.Owners {
+		if seen[result.Owner] {
+			continue
+		}
+		if typ := ownerType(result.Owner); typ == OwnerEmail || typ == OwnerRole {
+			continue
+		}
+		seen[result.Owner] = true
+
+		owner, err := r.Resolve(result.Owner)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, owner)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Owner < resolved[j].Owner
+	})
+	return resolved, nil
+}
+
+// Resolve looks up a @user or @org/team owner.
+func (r *OwnerResolver) Resolve(owner string) (ResolvedOwner, error) {
+	if resolved, ok := r.cache[owner]; ok {
+		return resolved, nil
+	}
+
+	var resolved ResolvedOwner
+	var err error
+	switch ownerType(owner) {
+	case OwnerTeam:
+		org, slug, _ := strings.Cut(strings.TrimPrefix(owner, "@"), "/")
+		resolved, err = r.resolveTeam(owner, org, slug)
+	case OwnerUser:
+		resolved, err = r.resolveUser(owner, strings.TrimPrefix(owner, "@"))
+	default:
+		resolved = ResolvedOwner{Owner: owner, Problem: "not a GitHub user or team"}
+	}
+	if err != nil {
+		return ResolvedOwner{}, err
+	}
+
+	if resolved.Problem == "" && !hasWritePermission(resolved.Permission) {
+		resolved.Problem = "no write access to " + r.repository
+	}
+	r.cache[owner] = resolved
+	return resolved, nil
+}
+
+func (r *OwnerResolver) resolveUser(owner, login string) (ResolvedOwner, error) {
+	repoOwner, repoName, _ := strings.Cut(r.repository, "/")
+	data := struct {
+		User *struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Repository *struct {
+			Collaborators struct {
+				Edges []struct {
+					Permission string `json:"permission"`
+					Node       struct {
+						Login string `json:"login"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"collaborators"`
+		} `json:"repository"`
+	}{}
+	err := r.client.query(`
+		query UserPermission ($login: String!, $owner: String!, $name: String!) {
+			user(login: $login) {
+				login
+			}
+			repository(owner: $owner, name: $name) {
+				collaborators(query: $login, first: 100) {
+					edges {
+						permission
+						node {
+							login
+						}
+					}
+				}
+			}
+		}`,
+		map[string]interface{}{
+			"login": login,
+			"owner": repoOwner,
+			"name":  repoName,
+		},
+		&data,
+	)
+	// GitHub reports an unknown login as an error along with a null user.
+	if err != nil && !(errors.Is(err, errGraphqlNotFound) && data.User == nil) {
+		return ResolvedOwner{}, err
+	}
+
+	resolved := ResolvedOwner{Owner: owner}
+	if data.User == nil {
+		resolved.Problem = "user does not exist"
+		return resolved, nil
+	}
+	resolved.Exists = true
+	if data.Repository != nil {
+		for _, edge := range data.Repository.Collaborators.Edges {
+			if strings.EqualFold(edge.Node.Login, login) {
+				resolved.Permission = edge.Permission
+			}
+		}
+	}
+	return resolved, nil
+}
+
+func (r *OwnerResolver) resolveTeam(owner, org, slug string) (ResolvedOwner, error) {
+	resolved := ResolvedOwner{Owner: owner, Team: true}
+
+	members, found, err := r.client.getTeamMembers(org, slug)
+	if err != nil {
+		return ResolvedOwner{}, err
+	}
+	if !found {
+		resolved.Problem = "team does not exist"
+		return resolved, nil
+	}
+	resolved.Exists = true
+	resolved.Members = members
+
+	_, repoName, _ := strings.Cut(r.repository, "/")
+	data := struct {
+		Organization struct {
+			Team struct {
+				Repositories struct {
+					Edges []struct {
+						Permission string `json:"permission"`
+						Node       struct {
+							NameWithOwner string `json:"nameWithOwner"`
+						} `json:"node"`
+					} `json:"edges"`
+				} `json:"repositories"`
+			} `json:"team"`
+		} `json:"organization"`
+	}{}
+	err = r.client.query(`
+		query TeamPermission ($org: String!, $slug: String!, $name: String!) {
+			organization(login: $org) {
+				team(slug: $slug) {
+					repositories(query: $name, first: 100) {
+						edges {
+							permission
+							node {
+								nameWithOwner
+							}
+						}
+					}
+				}
+			}
+		}`,
+		map[string]interface{}{
+			"org":  org,
+			"slug": slug,
+			"name": repoName,
+		},
+		&data,
+	)
+	if err != nil {
+		return ResolvedOwner{}, err
+	}
+
+	for _, edge := range data.Organization.Team.Repositories.Edges {
+		if strings.EqualFold(edge.Node.NameWithOwner, r.repository) {
+			resolved.Permission = edge.Permission
+		}
+	}
+	return resolved, nil
+}
+
+func hasWritePermission(permission string) bool {
+	switch permission {
+	case "ADMIN", "MAINTAIN", "WRITE":
+		return true
+	}
+	return false
+}
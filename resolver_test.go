@@ -0,0 +1,89 @@
+package owners
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerResolverValidateOwners(t *testing.T) {
+	requests := newTestGraphQLServer(t, func(req graphqlRequest) interface{} {
+		switch {
+		case strings.Contains(req.Query, "UserPermission"):
+			login := req.Variables["login"].(string)
+			data := map[string]interface{}{
+				"user": nil,
+				"repository": map[string]interface{}{"collaborators": map[string]interface{}{"edges": []interface{}{
+					map[string]interface{}{"permission": "WRITE", "node": map[string]interface{}{"login": "alice"}},
+					map[string]interface{}{"permission": "READ", "node": map[string]interface{}{"login": "bob"}},
+				}}},
+			}
+			if login != "alice" && login != "bob" {
+				// GitHub answers unknown logins with an error.
+				return graphqlResponse{Data: data, Errors: []graphqlError{{
+					Type:    "NOT_FOUND",
+					Path:    []interface{}{"user"},
+					Message: "Could not resolve to a User with the login of '" + login + "'.",
+				}}}
+			}
+			data["user"] = map[string]interface{}{"login": login}
+			return data
+		case strings.Contains(req.Query, "TeamMembers"):
+			if req.Variables["org"] != "org" {
+				return graphqlResponse{Data: map[string]interface{}{"organization": nil}, Errors: []graphqlError{{
+					Type:    "NOT_FOUND",
+					Path:    []interface{}{"organization"},
+					Message: "Could not resolve to an Organization with the login of '" + req.Variables["org"].(string) + "'.",
+				}}}
+			}
+			if req.Variables["slug"] != "platform" {
+				return map[string]interface{}{"organization": map[string]interface{}{"team": nil}}
+			}
+			return map[string]interface{}{"organization": map[string]interface{}{"team": map[string]interface{}{"members": map[string]interface{}{
+				"nodes":    []interface{}{map[string]interface{}{"login": "alice"}, map[string]interface{}{"login": "carol"}},
+				"pageInfo": map[string]interface{}{"hasNextPage": false},
+			}}}}
+		case strings.Contains(req.Query, "TeamPermission"):
+			return map[string]interface{}{"organization": map[string]interface{}{"team": map[string]interface{}{"repositories": map[string]interface{}{"edges": []interface{}{
+				map[string]interface{}{"permission": "READ", "node": map[string]interface{}{"nameWithOwner": "org/repo-docs"}},
+				map[string]interface{}{"permission": "MAINTAIN", "node": map[string]interface{}{"nameWithOwner": "org/repo"}},
+			}}}}}
+		}
+		t.Fatalf("unexpected query %s", req.Query)
+		return nil
+	})
+
+	actions := &GitHubActions{Repository: "org/repo"}
+	resolver := actions.OwnerResolver()
+	resolved, err := resolver.ValidateOwners(FindResults{Owners: []FindResult{
+		{Owner: "@alice"},
+		{Owner: "@bob"},
+		{Owner: "@org/platform"},
+		{Owner: "@org/plaform"},
+		{Owner: "@typo-org/platform"},
+		{Owner: "@typo"},
+		{Owner: "dev@example.com"},
+		{Owner: "@@maintainer"},
+		{Owner: "dev"},
+		{Owner: "@alice", Optional: true},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []ResolvedOwner{
+		{Owner: "@alice", Exists: true, Permission: "WRITE"},
+		{Owner: "@bob", Exists: true, Permission: "READ", Problem: "no write access to org/repo"},
+		{Owner: "@org/plaform", Team: true, Problem: "team does not exist"},
+		{Owner: "@org/platform", Team: true, Exists: true, Members: []string{"@alice", "@carol"}, Permission: "MAINTAIN"},
+		{Owner: "@typo", Problem: "user does not exist"},
+		{Owner: "@typo-org/platform", Team: true, Problem: "team does not exist"},
+		{Owner: "dev", Problem: "not a GitHub user or team"},
+	}, resolved)
+	assert.Equal(t, "@bob: no write access to org/repo", resolved[1].String())
+
+	// Owners are looked up once.
+	numRequests := len(*requests)
+	_, err = resolver.Resolve("@org/platform")
+	require.NoError(t, err)
+	assert.Len(t, *requests, numRequests)
+}
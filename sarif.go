@@ -0,0 +1,107 @@
+package owners
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// Minimal subset of SARIF 2.1.0 needed to report lint diagnostics.
+// Spec: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+func WriteSARIF(w io.Writer, diagnostics []Diagnostic) error {
+	var checks []string
+	for check := range Checks {
+		checks = append(checks, check)
+	}
+	sort.Strings(checks)
+
+	driver := sarifDriver{
+		Name:           "owners",
+		InformationURI: "https://github.com/martin-vanta/owners",
+	}
+	for _, check := range checks {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:               check,
+			ShortDescription: sarifMessage{Text: Checks[check]},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, diagnostic := range diagnostics {
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = diagnostic.FilePath
+		location.PhysicalLocation.Region.StartLine = diagnostic.Line
+
+		results = append(results, sarifResult{
+			RuleID:    diagnostic.Check,
+			Level:     string(diagnostic.Severity),
+			Message:   sarifMessage{Text: diagnostic.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: driver},
+			Results: results,
+		}},
+	})
+}
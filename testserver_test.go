@@ -0,0 +1,78 @@
+package owners
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testRequest is a request received by a test server, with its JSON body
+// decoded.
+type testRequest struct {
+	method string
+	uri    string
+	body   map[string]interface{}
+}
+
+// testResponse is the answer of a test server to a request. A zero status
+// is 200 OK.
+type testResponse struct {
+	status int
+	header map[string]string
+	body   string
+}
+
+// newTestServer starts a stand-in for an HTTP API that answers requests with
+// respond and records them. Every request must set header to value. Requests
+// that fail checks are reported with t.Errorf and answered with 500, since
+// handlers do not run on the test goroutine.
+func newTestServer(t *testing.T, header, value string, respond func(req testRequest) testResponse) (string, *[]testRequest) {
+	var requests []testRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(header); got != value {
+			t.Errorf("%s %s: %s header is %q, want %q", r.Method, r.URL, header, got, value)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		req := testRequest{method: r.Method, uri: r.URL.RequestURI()}
+		data, err := io.ReadAll(r.Body)
+		if err == nil && len(data) > 0 {
+			err = json.Unmarshal(data, &req.body)
+		}
+		if err != nil {
+			t.Errorf("%s %s: invalid body: %s", r.Method, r.URL, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		requests = append(requests, req)
+
+		response := respond(req)
+		for key, value := range response.header {
+			w.Header().Set(key, value)
+		}
+		if response.status != 0 {
+			w.WriteHeader(response.status)
+		}
+		w.Write([]byte(response.body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &requests
+}
+
+// newTestRestServer responds to GET requests with the response for their
+// request URI, and with 404 for other URIs.
+func newTestRestServer(t *testing.T, header, value string, responses map[string]string) (string, *[]testRequest) {
+	return newTestServer(t, header, value, func(req testRequest) testResponse {
+		if req.method != "GET" {
+			return testResponse{}
+		}
+		response, ok := responses[req.uri]
+		if !ok {
+			return testResponse{status: http.StatusNotFound}
+		}
+		return testResponse{body: response}
+	})
+}